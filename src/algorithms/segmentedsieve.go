@@ -0,0 +1,50 @@
+// This file implements a segmented Sieve of Eratosthenes. Instead of sieving every
+// number up to the end of the range, it sieves only the requested window using the
+// base primes up to its square root, so memory stays proportional to the window
+// size. This makes it practical for spot-checking small windows near 10^12.
+
+package algorithms
+
+import (
+	"fmt"
+)
+
+func FindPrimesWithSegmentedSieve(start, end int) ([]int, error) {
+
+	if start < 2 {
+		start = 2
+	}
+	if end < start {
+		return nil, fmt.Errorf("invalid range %d to %d", start, end)
+	}
+
+	limit := 1
+	for (limit+1)*(limit+1) <= end {
+		limit++
+	}
+	basePrimes, err := FindPrimesWithEratosthenes(2, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	isComposite := make([]bool, end-start+1)
+	for _, p := range basePrimes {
+		// Start at the first multiple of p inside the window, but never below p*p
+		first := (start + p - 1) / p * p
+		if first < p*p {
+			first = p * p
+		}
+		for m := first; m <= end; m += p {
+			isComposite[m-start] = true
+		}
+	}
+
+	var primes []int
+	for i, composite := range isComposite {
+		if !composite {
+			primes = append(primes, start+i)
+		}
+	}
+
+	return primes, nil
+}
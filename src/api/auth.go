@@ -0,0 +1,206 @@
+// API key authentication and role-based access for the API server. Keys are loaded
+// from a JSON configuration file that also holds the tenants' quotas, for example:
+//
+//	{
+//	  "keys": [
+//	    {"name": "alice", "key": "secret-1", "role": "submitter", "tenant": "team-a"},
+//	    {"name": "worker-pool", "key": "secret-2", "role": "worker"},
+//	    {"name": "ops", "key": "secret-3", "role": "admin"}
+//	  ],
+//	  "tenants": [{"id": "team-a", "maxConcurrentJobs": 4}]
+//	}
+//
+// Submitters create jobs, workers fetch chunks and submit results, and admins can
+// read and change every job. A submitter sees the jobs of its tenant, or only its
+// own when it has no tenant, but only a job's owner reads its results or changes it.
+
+package api
+
+import (
+	"context"
+	"distributed-prime-number-generator/src/node"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+)
+
+type Role string
+
+const (
+	RoleSubmitter Role = "submitter"
+	RoleWorker    Role = "worker"
+	RoleAdmin     Role = "admin"
+)
+
+// Principal is the identity a request acts as
+type Principal struct {
+	Name            string
+	Role            Role
+	Tenant          string
+	FromCertificate bool
+}
+
+type AuthConfig struct {
+	Keys    []KeyEntry    `json:"keys"`
+	Tenants []TenantEntry `json:"tenants"`
+}
+
+type KeyEntry struct {
+	Name   string `json:"name"`
+	Key    string `json:"key"`
+	Role   Role   `json:"role"`
+	Tenant string `json:"tenant"`
+}
+
+type TenantEntry struct {
+	ID                string `json:"id"`
+	MaxConcurrentJobs int    `json:"maxConcurrentJobs"`
+	MaxRangeSize      int    `json:"maxRangeSize"`
+	MaxStoredPrimes   int    `json:"maxStoredPrimes"`
+}
+
+type principalKey struct{}
+
+// Used when no auth configuration is loaded, so every request has full access
+var anonymousAdmin = &Principal{Role: RoleAdmin}
+
+// LoadAuthConfig reads and checks an auth configuration file
+func LoadAuthConfig(path string) (*AuthConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read auth config - %v", err)
+	}
+
+	var config AuthConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("failed to parse auth config - %v", err)
+	}
+
+	tenants := make(map[string]bool)
+	for _, tenant := range config.Tenants {
+		if tenant.ID == "" {
+			return nil, fmt.Errorf("auth config has a tenant without an id")
+		}
+		tenants[tenant.ID] = true
+	}
+
+	keys := make(map[string]bool)
+	for _, entry := range config.Keys {
+		if entry.Name == "" || entry.Key == "" {
+			return nil, fmt.Errorf("auth config has a key without a name or value")
+		}
+		if keys[entry.Key] {
+			return nil, fmt.Errorf("auth config reuses the key of %s", entry.Name)
+		}
+		keys[entry.Key] = true
+
+		switch entry.Role {
+		case RoleSubmitter, RoleWorker, RoleAdmin:
+		default:
+			return nil, fmt.Errorf("auth config gives %s unknown role %q", entry.Name, entry.Role)
+		}
+
+		if entry.Tenant != "" && !tenants[entry.Tenant] {
+			return nil, fmt.Errorf("auth config assigns %s to unknown tenant %s", entry.Name, entry.Tenant)
+		}
+	}
+
+	return &config, nil
+}
+
+// Apply installs the keys on the server and the tenants' quotas on its coordinator
+func (c *AuthConfig) Apply(server *Server) {
+	server.Keys = make(map[string]*Principal)
+	for _, entry := range c.Keys {
+		server.Keys[entry.Key] = &Principal{
+			Name:   entry.Name,
+			Role:   entry.Role,
+			Tenant: entry.Tenant,
+		}
+	}
+
+	for _, tenant := range c.Tenants {
+		server.Coordinator.SetTenantQuota(tenant.ID, node.TenantQuota{
+			MaxConcurrentJobs: tenant.MaxConcurrentJobs,
+			MaxRangeSize:      tenant.MaxRangeSize,
+			MaxStoredPrimes:   tenant.MaxStoredPrimes,
+		})
+	}
+}
+
+// requireRole wraps a handler so it only runs for requests authenticated with one
+// of the given roles. The caller's principal is available via requestPrincipal.
+func (s *Server) requireRole(handler http.HandlerFunc, roles ...Role) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal, ok := s.authenticate(r)
+		if !ok {
+			sendErrorResponse(w, "Missing or invalid API key", http.StatusUnauthorized)
+			return
+		}
+
+		if !s.hasRole(principal, roles...) {
+			sendErrorResponse(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+
+		handler(w, r.WithContext(context.WithValue(r.Context(), principalKey{}, principal)))
+	}
+}
+
+// hasRole reports whether a principal holds one of the given roles. Without an
+// auth config anonymous requests may use every endpoint, except that worker
+// endpoints need a certificate once client CAs are configured.
+func (s *Server) hasRole(principal *Principal, roles ...Role) bool {
+	if principal == anonymousAdmin && s.ClientCAFile == "" {
+		return true
+	}
+	for _, role := range roles {
+		if principal.Role == role {
+			return true
+		}
+	}
+	return false
+}
+
+// authenticate resolves the principal from the X-API-Key header or a bearer token,
+// falling back to a verified client certificate
+func (s *Server) authenticate(r *http.Request) (*Principal, bool) {
+	key := r.Header.Get("X-API-Key")
+	if key == "" {
+		key = strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+	}
+
+	if key != "" && s.Keys != nil {
+		principal, ok := s.Keys[key]
+		return principal, ok
+	}
+
+	if principal, ok := certificatePrincipal(r.TLS); ok {
+		return principal, true
+	}
+
+	if s.Keys == nil {
+		return anonymousAdmin, true
+	}
+
+	return nil, false
+}
+
+func requestPrincipal(r *http.Request) *Principal {
+	if principal, ok := r.Context().Value(principalKey{}).(*Principal); ok {
+		return principal
+	}
+	return anonymousAdmin
+}
+
+// canSeeJob reports whether a principal may list a job and follow its progress
+func canSeeJob(principal *Principal, owner, tenant string) bool {
+	return canUseJob(principal, owner) || (principal.Tenant != "" && principal.Tenant == tenant)
+}
+
+// canUseJob reports whether a principal may read a job's results or change it
+func canUseJob(principal *Principal, owner string) bool {
+	return principal.Role == RoleAdmin || principal.Name == owner
+}
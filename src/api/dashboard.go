@@ -0,0 +1,20 @@
+// Web dashboard for watching the cluster and submitting jobs. Its static assets are
+// embedded in the server binary and served under /dashboard/; the page itself talks
+// to the REST API and follows the /api/v1/events stream for live updates, so it needs
+// an admin key when authentication is enabled.
+
+package api
+
+import (
+	"embed"
+	"net/http"
+)
+
+//go:embed dashboard
+var dashboardFiles embed.FS
+
+// handleDashboard serves the dashboard's assets. Their paths within the embedded
+// files match the URL paths, /dashboard/index.html and so on.
+func (s *Server) handleDashboard() http.HandlerFunc {
+	return http.FileServerFS(dashboardFiles).ServeHTTP
+}
@@ -0,0 +1,293 @@
+// Dashboard client. Jobs and workers are loaded from the REST API and reloaded when
+// the /api/v1/events stream reports a change to them, so the page stays current
+// without polling. EventSource can't send the API key header, so the stream is
+// read with fetch and parsed here.
+"use strict";
+
+const MAX_COMPLETIONS = 50;
+const RECONNECT_DELAY = 3000;
+const RELOAD_DELAY = 300; // batches the reloads triggered by bursts of events
+
+let apiKey = localStorage.getItem("apiKey") || "";
+let stream = null;
+let workers = [];
+const completions = [];
+const reloadTimers = {};
+
+function headers() {
+  const result = { "Content-Type": "application/json" };
+  if (apiKey) {
+    result["X-API-Key"] = apiKey;
+  }
+  return result;
+}
+
+async function request(method, path, body) {
+  const response = await fetch(path, {
+    method,
+    headers: headers(),
+    body: body === undefined ? undefined : JSON.stringify(body),
+  });
+  const data = await response.json().catch(() => ({}));
+  if (!response.ok) {
+    throw new Error(data.detail || data.title || response.statusText);
+  }
+  return data;
+}
+
+function element(tag, text, className) {
+  const node = document.createElement(tag);
+  if (text !== undefined) {
+    node.textContent = text;
+  }
+  if (className) {
+    node.className = className;
+  }
+  return node;
+}
+
+function row(cells) {
+  const tr = document.createElement("tr");
+  for (const cell of cells) {
+    const td = document.createElement("td");
+    td.append(cell);
+    tr.append(td);
+  }
+  return tr;
+}
+
+function badge(state) {
+  return element("span", state, "status " + state);
+}
+
+function progressBar(fraction) {
+  const percent = Math.floor(fraction * 1000) / 10;
+  const container = element("div", undefined, "progress");
+  const bar = element("div", undefined, "bar");
+  bar.style.width = percent + "%";
+  container.append(bar, element("span", percent + "%", "label"));
+  return container;
+}
+
+function ago(timestamp) {
+  const seconds = Math.max(0, Math.round((Date.now() - Date.parse(timestamp)) / 1000));
+  if (seconds < 60) {
+    return seconds + "s ago";
+  }
+  if (seconds < 3600) {
+    return Math.floor(seconds / 60) + "m ago";
+  }
+  return Math.floor(seconds / 3600) + "h ago";
+}
+
+function showError(id, err) {
+  const node = document.getElementById(id);
+  node.textContent = err.message;
+  node.className = "empty error";
+  node.hidden = false;
+}
+
+async function loadJobs() {
+  let page;
+  try {
+    page = await request("GET", "/api/v1/jobs?sort=-createdAt&limit=500");
+  } catch (err) {
+    document.getElementById("jobs").replaceChildren();
+    showError("jobs-empty", err);
+    return;
+  }
+
+  const active = page.jobs.filter((job) => ["queued", "running", "paused"].includes(job.state));
+  document.getElementById("jobs").replaceChildren(...active.map((job) => row([
+    job.id,
+    badge(job.state),
+    job.start.toLocaleString() + " – " + job.end.toLocaleString(),
+    progressBar(job.progress),
+    job.chunksCompleted + " / " + job.chunksCreated,
+    job.primesFound.toLocaleString(),
+  ])));
+
+  const empty = document.getElementById("jobs-empty");
+  empty.textContent = "No active jobs.";
+  empty.className = "empty";
+  empty.hidden = active.length > 0;
+}
+
+async function loadWorkers() {
+  try {
+    workers = (await request("GET", "/api/v1/workers")).workers;
+  } catch (err) {
+    workers = [];
+    document.getElementById("workers").replaceChildren();
+    showError("workers-empty", err);
+    return;
+  }
+  renderWorkers();
+}
+
+function renderWorkers() {
+  document.getElementById("workers").replaceChildren(...workers.map((worker) => {
+    const throughput = Object.entries(worker.throughput || {})
+      .map(([algorithm, rate]) => algorithm + ": " + Math.round(rate).toLocaleString() + "/s")
+      .join(", ");
+    return row([
+      worker.id,
+      badge(worker.state),
+      ago(worker.lastHeartbeat),
+      String((worker.activeChunks || []).length),
+      String(worker.completedChunks),
+      throughput || "–",
+    ]);
+  }));
+
+  const empty = document.getElementById("workers-empty");
+  empty.textContent = "No workers registered.";
+  empty.className = "empty";
+  empty.hidden = workers.length > 0;
+}
+
+function addCompletion(event) {
+  completions.unshift(event);
+  completions.length = Math.min(completions.length, MAX_COMPLETIONS);
+
+  document.getElementById("completions").replaceChildren(...completions.map((completion) => element("li",
+    new Date(completion.time).toLocaleTimeString() + "  " + completion.chunkId +
+    "  " + completion.primes.toLocaleString() + " primes" +
+    (completion.workerId ? "  by " + completion.workerId : ""))));
+  document.getElementById("completions-empty").hidden = true;
+}
+
+// scheduleReload runs a loader once things have settled, however many events ask for it
+function scheduleReload(name, loader) {
+  clearTimeout(reloadTimers[name]);
+  reloadTimers[name] = setTimeout(loader, RELOAD_DELAY);
+}
+
+function handleEvent(type, event) {
+  switch (type) {
+    case "chunk-completed":
+      addCompletion(event);
+      scheduleReload("jobs", loadJobs);
+      scheduleReload("workers", loadWorkers);
+      break;
+    case "chunk-assigned":
+      scheduleReload("workers", loadWorkers);
+      break;
+    case "job-completed":
+    case "job-state-changed":
+      scheduleReload("jobs", loadJobs);
+      break;
+    case "worker-joined":
+    case "worker-lost":
+      scheduleReload("workers", loadWorkers);
+      break;
+  }
+}
+
+function setConnection(state) {
+  const node = document.getElementById("connection");
+  node.textContent = state;
+  node.className = "status " + state;
+}
+
+// follow reads the cluster event stream until it ends, then reconnects
+async function follow() {
+  const controller = new AbortController();
+  stream = controller;
+  setConnection("connecting");
+
+  try {
+    const response = await fetch("/api/v1/events", { headers: headers(), signal: controller.signal });
+    if (!response.ok) {
+      const data = await response.json().catch(() => ({}));
+      throw new Error(data.detail || data.title || response.statusText);
+    }
+    setConnection("connected");
+    // Catch up on anything missed while disconnected
+    loadJobs();
+    loadWorkers();
+
+    const reader = response.body.pipeThrough(new TextDecoderStream()).getReader();
+    let buffer = "";
+    for (;;) {
+      const { value, done } = await reader.read();
+      if (done) {
+        break;
+      }
+      buffer += value;
+
+      let end;
+      while ((end = buffer.indexOf("\n\n")) >= 0) {
+        const message = buffer.slice(0, end);
+        buffer = buffer.slice(end + 2);
+
+        let type = "message";
+        let data = "";
+        for (const line of message.split("\n")) {
+          if (line.startsWith("event: ")) {
+            type = line.slice(7);
+          } else if (line.startsWith("data: ")) {
+            data += line.slice(6);
+          }
+        }
+        if (data) {
+          handleEvent(type, JSON.parse(data));
+        }
+      }
+    }
+  } catch (err) {
+    if (controller.signal.aborted) {
+      return;
+    }
+    console.warn("Event stream failed:", err);
+  }
+
+  if (stream === controller) {
+    setConnection("disconnected");
+    setTimeout(() => {
+      if (stream === controller) {
+        follow();
+      }
+    }, RECONNECT_DELAY);
+  }
+}
+
+function connect() {
+  if (stream) {
+    stream.abort();
+  }
+  follow();
+}
+
+document.getElementById("key-form").addEventListener("submit", (event) => {
+  event.preventDefault();
+  apiKey = document.getElementById("api-key").value;
+  localStorage.setItem("apiKey", apiKey);
+  connect();
+});
+
+document.getElementById("submit-form").addEventListener("submit", async (event) => {
+  event.preventDefault();
+  const body = {};
+  for (const [name, value] of new FormData(event.target)) {
+    if (value !== "") {
+      body[name] = Number(value);
+    }
+  }
+
+  const result = document.getElementById("submit-result");
+  try {
+    const response = await request("POST", "/api/v1/jobs", body);
+    result.textContent = "Created " + response.jobId;
+    result.className = "";
+    loadJobs();
+  } catch (err) {
+    result.textContent = err.message;
+    result.className = "error";
+  }
+});
+
+document.getElementById("api-key").value = apiKey;
+// Keeps the "last seen" column current between updates
+setInterval(renderWorkers, 5000);
+connect();
@@ -0,0 +1,97 @@
+// Server-Sent Events streams of coordinator events. Clients can follow a single job
+// on /api/v1/jobs/{id}/events, or the whole cluster on /api/v1/events, instead of polling.
+
+package api
+
+import (
+	"distributed-prime-number-generator/src/node"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Comment lines sent while a stream is idle so proxies don't drop the connection
+const eventKeepAliveInterval = 15 * time.Second
+
+// handleClusterEvents streams every event in the cluster
+func (s *Server) handleClusterEvents(w http.ResponseWriter, r *http.Request) {
+	s.streamEvents(w, r, "")
+}
+
+// streamEvents sends events to the client as they are published until it
+// disconnects. A job's stream ends once the job reaches a final state; a job that
+// already has gets just the event that finished it.
+func (s *Server) streamEvents(w http.ResponseWriter, r *http.Request, jobID string) {
+	controller := http.NewResponseController(w)
+
+	subscription := s.Coordinator.Events.Subscribe(jobID)
+	defer subscription.Close()
+
+	// Looked up after subscribing, so a job finishing in between is caught either way
+	var final *node.Event
+	if jobID != "" {
+		var err error
+		if final, err = s.Coordinator.FinalJobEvent(jobID); err != nil {
+			sendErrorResponse(w, fmt.Sprintf("Error: %v", err), http.StatusNotFound)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(http.StatusOK)
+	if final != nil {
+		writeEvent(w, *final)
+		controller.Flush()
+		return
+	}
+	if err := controller.Flush(); err != nil {
+		return
+	}
+
+	keepAlive := time.NewTicker(eventKeepAliveInterval)
+	defer keepAlive.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			fmt.Fprint(w, ": keep-alive\n\n")
+		case event, ok := <-subscription.Events:
+			if !ok {
+				// Dropped for falling behind; the client reconnects
+				return
+			}
+
+			if err := writeEvent(w, event); err != nil {
+				return
+			}
+
+			if jobID != "" && event.State.Terminal() {
+				controller.Flush()
+				return
+			}
+		}
+
+		if err := controller.Flush(); err != nil {
+			return
+		}
+	}
+}
+
+// writeEvent sends an event in Server-Sent Events format, leaving out the ID of an
+// event that wasn't published
+func writeEvent(w http.ResponseWriter, event node.Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event - %v", err)
+	}
+	if event.ID != 0 {
+		fmt.Fprintf(w, "id: %d\n", event.ID)
+	}
+	fmt.Fprintf(w, "event: %s\ndata: %s\n\n", event.Type, data)
+	return nil
+}
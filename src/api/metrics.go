@@ -0,0 +1,63 @@
+// Prometheus metrics for the API server. Every route is instrumented with request
+// counts and latencies, and /metrics exposes them together with the coordinator's
+// chunk, job and worker metrics and the Go runtime's own.
+
+package api
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"net/http"
+)
+
+type httpMetrics struct {
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+}
+
+// newMetricsRegistry creates the registry served on /metrics
+func (s *Server) newMetricsRegistry() *prometheus.Registry {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+
+	s.httpMetrics = &httpMetrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "primes",
+			Subsystem: "http",
+			Name:      "requests_total",
+			Help:      "API requests by route, method and status code.",
+		}, []string{"route", "method", "code"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "primes",
+			Subsystem: "http",
+			Name:      "request_duration_seconds",
+			Help:      "API request latency by route and method.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"route", "method"}),
+	}
+	registry.MustRegister(s.httpMetrics.requests, s.httpMetrics.duration)
+	registry.MustRegister(s.Coordinator.Collectors()...)
+
+	return registry
+}
+
+// instrument records request metrics and traces for a route. The route pattern is
+// used as the label rather than the path so job and worker IDs don't create new
+// series.
+func (s *Server) instrument(route string, handler http.HandlerFunc) http.HandlerFunc {
+	labels := prometheus.Labels{"route": route}
+	requests := s.httpMetrics.requests.MustCurryWith(labels)
+	duration := s.httpMetrics.duration.MustCurryWith(labels)
+
+	return promhttp.InstrumentHandlerCounter(requests,
+		promhttp.InstrumentHandlerDuration(duration, traced(route, handler)))
+}
+
+// handleMetrics serves the metrics in the Prometheus text format
+func (s *Server) handleMetrics() http.Handler {
+	return promhttp.HandlerFor(s.Metrics, promhttp.HandlerOpts{})
+}
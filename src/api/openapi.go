@@ -0,0 +1,180 @@
+// OpenAPI description of the API and validation against it. The spec is embedded
+// from openapi.json and served on /api/v1/openapi.json. Requests that don't match it
+// are rejected with a 400 problem before reaching a handler, and responses are
+// checked too: a response that doesn't match is still sent, but logged as an error
+// so the spec and the handlers can't drift apart unnoticed.
+
+package api
+
+import (
+	"bytes"
+	"context"
+	_ "embed"
+	"fmt"
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/legacy"
+	"io"
+	"log/slog"
+	"net/http"
+)
+
+//go:embed openapi.json
+var openAPISpec []byte
+
+// Responses larger than this are passed through without being validated, so large
+// result lists don't have to be buffered
+const maxValidatedResponse = 1 << 20
+
+// loadOpenAPI parses and checks the embedded spec and builds the router used to
+// match requests to its operations
+func loadOpenAPI() (routers.Router, error) {
+	// Validation errors are sent to clients, so they shouldn't quote whole schemas
+	openapi3.SchemaErrorDetailsDisabled = true
+
+	loader := openapi3.NewLoader()
+	spec, err := loader.LoadFromData(openAPISpec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse OpenAPI spec - %v", err)
+	}
+	if err := spec.Validate(loader.Context); err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI spec - %v", err)
+	}
+
+	router, err := legacy.NewRouter(spec)
+	if err != nil {
+		return nil, fmt.Errorf("failed to route OpenAPI spec - %v", err)
+	}
+	return router, nil
+}
+
+// handleOpenAPI serves the spec
+func (s *Server) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(openAPISpec)
+}
+
+// validated checks requests and responses of a handler against the spec. Requests
+// for paths or methods the spec doesn't describe are left to the handler, which
+// rejects them.
+func (s *Server) validated(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		route, pathParams, err := s.openAPIRouter.FindRoute(r)
+		if err != nil {
+			handler(w, r)
+			return
+		}
+
+		// Clients that send a body without saying what it is get it treated as JSON
+		if r.ContentLength != 0 && r.Header.Get("Content-Type") == "" {
+			r.Header.Set("Content-Type", "application/json")
+		}
+
+		input := &openapi3filter.RequestValidationInput{
+			Request:    r,
+			PathParams: pathParams,
+			Route:      route,
+			Options: &openapi3filter.Options{
+				// Authentication and roles are checked by requireRole
+				AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+			},
+		}
+		if err := openapi3filter.ValidateRequest(r.Context(), input); err != nil {
+			sendErrorResponse(w, validationMessage(err), http.StatusBadRequest)
+			return
+		}
+
+		// Event streams are long-lived, so they are passed straight through
+		if isEventStream(route) {
+			handler(w, r)
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		handler(recorder, r)
+		if recorder.passthrough {
+			return
+		}
+
+		s.validateResponse(r.Context(), input, recorder)
+		recorder.flush()
+	}
+}
+
+// validateResponse logs responses that don't match the spec
+func (s *Server) validateResponse(ctx context.Context, input *openapi3filter.RequestValidationInput, recorder *responseRecorder) {
+	output := &openapi3filter.ResponseValidationInput{
+		RequestValidationInput: input,
+		Status:                 recorder.status,
+		Header:                 recorder.Header(),
+		Body:                   io.NopCloser(bytes.NewReader(recorder.body.Bytes())),
+		Options:                &openapi3filter.Options{IncludeResponseStatus: true},
+	}
+	if err := openapi3filter.ValidateResponse(ctx, output); err != nil {
+		slog.Error("Response does not match the OpenAPI spec", "method", input.Request.Method,
+			"path", input.Request.URL.Path, "status", recorder.status, "error", err)
+	}
+}
+
+// validationMessage shortens the validator's description of a bad request
+func validationMessage(err error) string {
+	switch err := err.(type) {
+	case *openapi3filter.RequestError:
+		detail := err.Reason
+		if err.Err != nil {
+			detail = err.Err.Error()
+		}
+		if err.Parameter != nil {
+			return fmt.Sprintf("Invalid %s parameter %s: %s", err.Parameter.In, err.Parameter.Name, detail)
+		}
+		if err.RequestBody != nil {
+			return fmt.Sprintf("Invalid request body: %s", detail)
+		}
+	}
+	return err.Error()
+}
+
+func isEventStream(route *routers.Route) bool {
+	response := route.Operation.Responses.Status(http.StatusOK)
+	return response != nil && response.Value != nil && response.Value.Content.Get("text/event-stream") != nil
+}
+
+// responseRecorder holds back a response until it has been validated. Once the
+// body grows past maxValidatedResponse it gives up and writes everything through.
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+	passthrough bool
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if r.wroteHeader {
+		return
+	}
+	r.status = status
+	r.wroteHeader = true
+}
+
+func (r *responseRecorder) Write(data []byte) (int, error) {
+	r.wroteHeader = true
+	if r.passthrough {
+		return r.ResponseWriter.Write(data)
+	}
+
+	if r.body.Len()+len(data) > maxValidatedResponse {
+		r.passthrough = true
+		r.flush()
+		return r.ResponseWriter.Write(data)
+	}
+	return r.body.Write(data)
+}
+
+// flush writes the held back status and body
+func (r *responseRecorder) flush() {
+	r.ResponseWriter.WriteHeader(r.status)
+	r.ResponseWriter.Write(r.body.Bytes())
+	r.body.Reset()
+}
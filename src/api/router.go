@@ -0,0 +1,174 @@
+// Routing of API requests. Endpoints live under /api/v1 and are matched by method
+// and path pattern, so requests for anything else get a 404 or 405 problem from one
+// place. The unversioned /api paths of earlier releases are still served as
+// deprecated aliases that point clients at their /api/v1 successors.
+
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+const (
+	apiPrefix       = "/api/v1"
+	legacyAPIPrefix = "/api"
+)
+
+// jobAccess is the access to a job a route needs
+type jobAccess int
+
+const (
+	seeJob jobAccess = iota // follow its progress, as its tenant may
+	useJob                  // read its results or change it, as only its owner may
+)
+
+// Handler returns the server's routes
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	s.route(mux, "GET /jobs", s.handleListJobs, RoleSubmitter, RoleAdmin)
+	s.route(mux, "POST /jobs", s.handleCreateJob, RoleSubmitter, RoleAdmin)
+	s.route(mux, "GET /jobs/{id}", s.forJob(useJob, s.handleJobResults), RoleSubmitter, RoleAdmin)
+	s.route(mux, "PATCH /jobs/{id}", s.forJob(useJob, s.handleUpdateJob), RoleSubmitter, RoleAdmin)
+	s.route(mux, "GET /jobs/{id}/status", s.forJob(seeJob, s.handleJobStatus), RoleSubmitter, RoleAdmin)
+	s.route(mux, "GET /jobs/{id}/manifest", s.forJob(useJob, s.handleJobManifest), RoleSubmitter, RoleAdmin)
+	s.route(mux, "GET /jobs/{id}/events", s.forJob(seeJob, s.streamEvents), RoleSubmitter, RoleAdmin)
+	s.route(mux, "POST /jobs/{id}/cancel", s.forJob(useJob, s.handleCancelJob), RoleSubmitter, RoleAdmin)
+	s.route(mux, "POST /jobs/{id}/pause", s.forJob(useJob, s.handlePauseJob), RoleSubmitter, RoleAdmin)
+	s.route(mux, "POST /jobs/{id}/resume", s.forJob(useJob, s.handleResumeJob), RoleSubmitter, RoleAdmin)
+
+	// Workers register themselves and trade chunks, admins inspect and manage them
+	s.route(mux, "GET /workers", s.handleListWorkers, RoleAdmin)
+	s.route(mux, "POST /workers", s.handleRegisterWorker, RoleWorker)
+	s.route(mux, "GET /workers/{id}", s.forWorker(s.handleGetWorker), RoleAdmin)
+	s.route(mux, "GET /workers/{id}/chunks", s.forWorker(s.handleGetNextChunk), RoleWorker)
+	s.route(mux, "POST /workers/{id}/results", s.forWorker(s.handleSubmitResults), RoleWorker)
+	s.route(mux, "POST /workers/{id}/drain", s.forWorker(s.handleDrainWorker), RoleAdmin)
+	s.route(mux, "POST /workers/{id}/evict", s.forWorker(s.handleEvictWorker), RoleAdmin)
+
+	s.route(mux, "GET /events", s.handleClusterEvents, RoleAdmin)
+	s.handle(mux, "GET /openapi.json", s.handleOpenAPI)
+
+	mux.Handle("GET /metrics", s.handleMetrics())
+	mux.HandleFunc("GET /dashboard/", s.instrument("/dashboard/", s.handleDashboard()))
+
+	return problemsFor(mux)
+}
+
+// route registers an API endpoint for the given roles, validated against the spec
+func (s *Server) route(mux *http.ServeMux, pattern string, handler http.HandlerFunc, roles ...Role) {
+	s.handle(mux, pattern, s.requireRole(s.validated(handler), roles...))
+}
+
+// handle registers a handler under /api/v1 and its deprecated unversioned alias.
+// The pattern is a method and a path relative to the API prefix.
+func (s *Server) handle(mux *http.ServeMux, pattern string, handler http.HandlerFunc) {
+	method, path, _ := strings.Cut(pattern, " ")
+
+	mux.HandleFunc(method+" "+apiPrefix+path, s.instrument(apiPrefix+path, handler))
+	mux.HandleFunc(method+" "+legacyAPIPrefix+path, s.instrument(legacyAPIPrefix+path, deprecated(handler)))
+}
+
+// deprecated serves an unversioned request with the /api/v1 handler, telling the
+// client where the endpoint has moved. The path is rewritten so the request is
+// validated against the /api/v1 operation.
+func deprecated(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		successor := apiPrefix + strings.TrimPrefix(r.URL.Path, legacyAPIPrefix)
+		w.Header().Set("Deprecation", "true")
+		w.Header().Set("Link", fmt.Sprintf("<%s>; rel=\"successor-version\"", successor))
+
+		r = r.Clone(r.Context())
+		r.URL.Path = successor
+		r.URL.RawPath = ""
+		handler(w, r)
+	}
+}
+
+// forJob passes the job named in the path to a handler if the caller has the access
+// the route needs. Jobs the caller can't see are reported as missing rather than
+// forbidden so their IDs can't be probed.
+func (s *Server) forJob(access jobAccess, handler func(http.ResponseWriter, *http.Request, string)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		jobID := r.PathValue("id")
+		principal := requestPrincipal(r)
+		owner, tenant, err := s.Coordinator.JobOwner(jobID)
+		if err != nil || !canSeeJob(principal, owner, tenant) {
+			sendErrorResponse(w, fmt.Sprintf("Error: job not found: %s", jobID), http.StatusNotFound)
+			return
+		}
+		if access == useJob && !canUseJob(principal, owner) {
+			sendErrorResponse(w, fmt.Sprintf("Only the owner of job %s may do this", jobID), http.StatusForbidden)
+			return
+		}
+		handler(w, r, jobID)
+	}
+}
+
+// forWorker passes the worker named in the path to a handler
+func (s *Server) forWorker(handler func(http.ResponseWriter, *http.Request, string)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		workerID := r.PathValue("id")
+		owner, registered := s.Coordinator.WorkerOwner(workerID)
+
+		// Workers only act as themselves: a certificate speaks for the worker it
+		// names, and an API key for the workers registered with it
+		principal := requestPrincipal(r)
+		if principal.Role == RoleWorker && ((principal.FromCertificate && principal.Name != workerID) ||
+			(registered && owner != principal.Name)) {
+			sendErrorResponse(w, "Forbidden", http.StatusForbidden)
+			return
+		}
+
+		if !registered {
+			sendErrorResponse(w, fmt.Sprintf("Worker not found: %s", workerID), http.StatusNotFound)
+			return
+		}
+		handler(w, r, workerID)
+	}
+}
+
+// problemsFor answers requests no route matches with a problem instead of the
+// mux's plain text, keeping the Allow header it sets for a 405
+func problemsFor(mux *http.ServeMux) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler, pattern := mux.Handler(r)
+		if pattern != "" {
+			mux.ServeHTTP(w, r)
+			return
+		}
+
+		unmatched := &unmatchedRecorder{header: http.Header{}, status: http.StatusNotFound}
+		handler.ServeHTTP(unmatched, r)
+		if allow := unmatched.header.Get("Allow"); allow != "" {
+			w.Header().Set("Allow", allow)
+		}
+
+		message := fmt.Sprintf("Not found: %s", r.URL.Path)
+		if unmatched.status == http.StatusMethodNotAllowed {
+			message = fmt.Sprintf("Method %s not allowed on %s", r.Method, r.URL.Path)
+		}
+		sendErrorResponse(w, message, unmatched.status)
+	})
+}
+
+// unmatchedRecorder captures the status and headers of the mux's fallback handlers,
+// discarding their body
+type unmatchedRecorder struct {
+	header http.Header
+	status int
+}
+
+func (r *unmatchedRecorder) Header() http.Header {
+	return r.header
+}
+
+func (r *unmatchedRecorder) WriteHeader(status int) {
+	r.status = status
+}
+
+func (r *unmatchedRecorder) Write(data []byte) (int, error) {
+	return len(data), nil
+}
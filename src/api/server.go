@@ -6,61 +6,83 @@
 package api
 
 import (
+	"distributed-prime-number-generator/src/models"
 	"distributed-prime-number-generator/src/node"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/prometheus/client_golang/prometheus"
+	"io"
+	"log/slog"
 	"net/http"
-	"strings"
+	"strconv"
 	"time"
 )
 
 type Server struct {
 	Coordinator *node.Coordinator
 	Port        int
+	Keys        map[string]*Principal // API key to principal, nil when auth is disabled
+	TLSCertFile  string
+	TLSKeyFile   string
+	ClientCAFile string // CA bundle for verifying worker client certificates
+	Metrics      *prometheus.Registry
+
+	httpMetrics   *httpMetrics
+	openAPIRouter routers.Router
 }
 
 func NewServer(coordinator *node.Coordinator, port int) *Server {
-	return &Server{
+	server := &Server{
 		Coordinator: coordinator,
 		Port:        port,
 	}
+	server.Metrics = server.newMetricsRegistry()
+	
+	// The spec is embedded, so it can only be broken by a bad build
+	router, err := loadOpenAPI()
+	if err != nil {
+		panic(err)
+	}
+	server.openAPIRouter = router
+	return server
 }
 
 func (s *Server) Start() error {
-	// Register endpoints
-	http.HandleFunc("/api/jobs", s.handleJobs)
-	http.HandleFunc("/api/jobs/", s.handleJobById)
-	http.HandleFunc("/api/workers", s.handleWorkers)
-	http.HandleFunc("/api/workers/", s.handleWorkerById)
+	server := &http.Server{
+		Addr:     fmt.Sprintf(":%d", s.Port),
+		Handler:  s.Handler(),
+		ErrorLog: slog.NewLogLogger(slog.Default().Handler(), slog.LevelError),
+	}
+	if s.TLSCertFile == "" {
+		slog.Info("API server listening", "addr", server.Addr, "tls", false)
+		return server.ListenAndServe()
+	}
 	
-	// Start the server
-	addr := fmt.Sprintf(":%d", s.Port)
-	fmt.Printf("API server listening on %s\n", addr)
-	return http.ListenAndServe(addr, nil)
-}
-
-type CreateJobRequest struct {
-	Start     int `json:"start"`
-	End       int `json:"end"`
-	Rounds	  int `json:"rounds"`
-	ChunkSize int `json:"chunkSize"`
-}
-
-type JobResponse struct {
-	JobID string `json:"jobId"`
+	tlsConfig, err := s.tlsConfig()
+	if err != nil {
+		return err
+	}
+	
+	server.TLSConfig = tlsConfig
+	slog.Info("API server listening", "addr", server.Addr, "tls", true)
+	return server.ListenAndServeTLS(s.TLSCertFile, s.TLSKeyFile)
 }
 
-type ErrorResponse struct {
-	Error string `json:"error"`
-}
+type (
+	CreateJobRequest      = models.CreateJobRequest
+	UpdateJobRequest      = models.UpdateJobRequest
+	PauseJobRequest       = models.PauseJobRequest
+	JobResponse           = models.JobResponse
+	JobListResponse       = models.JobListResponse
+	RegisterWorkerRequest = models.RegisterWorkerRequest
+	WorkerListResponse    = models.WorkerListResponse
+	Problem               = models.Problem // RFC 7807 problem details error response
+)
 
-func (s *Server) handleJobs(w http.ResponseWriter, r *http.Request) {
-	// Only support POST method for now
-	if r.Method != http.MethodPost {
-		sendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-	
+// handleCreateJob submits a job on behalf of the caller
+func (s *Server) handleCreateJob(w http.ResponseWriter, r *http.Request) {
 	var req CreateJobRequest
 	decoder := json.NewDecoder(r.Body)
 	if err := decoder.Decode(&req); err != nil {
@@ -78,12 +100,50 @@ func (s *Server) handleJobs(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	
+	if req.End > node.MaxRangeEnd {
+		sendErrorResponse(w, fmt.Sprintf("End must be at most %d", node.MaxRangeEnd), http.StatusBadRequest)
+		return
+	}
+	
+	if req.Rounds < 0 || req.Rounds > node.MaxRounds {
+		sendErrorResponse(w, fmt.Sprintf("Rounds must be between 0 and %d", node.MaxRounds), http.StatusBadRequest)
+		return
+	}
+	
+	if req.Replication < 0 || req.Replication > node.MaxReplication {
+		sendErrorResponse(w, fmt.Sprintf("Replication must be between 0 and %d (0 means 1)", node.MaxReplication), http.StatusBadRequest)
+		return
+	}
+	
+	if req.Priority < node.MinPriority || req.Priority > node.MaxPriority {
+		sendErrorResponse(w, fmt.Sprintf("Priority must be between %d and %d", node.MinPriority, node.MaxPriority), http.StatusBadRequest)
+		return
+	}
+	
 	if req.ChunkSize <= 0 {
 		// Default chunk size
 		req.ChunkSize = 10000
 	}
 	
-	jobID, err := s.Coordinator.CreateJob(req.Start, req.End, req.Rounds, req.ChunkSize)
+	jobID, err := s.Coordinator.CreateJob(r.Context(), node.JobSpec{
+		Start:       req.Start,
+		End:         req.End,
+		Rounds:      req.Rounds,
+		ChunkSize:   req.ChunkSize,
+		Replication: req.Replication,
+		Priority:    req.Priority,
+		Tenant:      requestPrincipal(r).Tenant,
+		Owner:       requestPrincipal(r).Name,
+		Callbacks:   req.Callbacks,
+	})
+	if errors.Is(err, node.ErrInvalidCallback) || errors.Is(err, node.ErrWebhooksDisabled) {
+		sendErrorResponse(w, fmt.Sprintf("Failed to create job: %v", err), http.StatusBadRequest)
+		return
+	}
+	if errors.Is(err, node.ErrQuotaExceeded) {
+		sendErrorResponse(w, fmt.Sprintf("Failed to create job: %v", err), http.StatusTooManyRequests)
+		return
+	}
 	if err != nil {
 		sendErrorResponse(w, fmt.Sprintf("Failed to create job: %v", err), http.StatusInternalServerError)
 		return
@@ -93,89 +153,243 @@ func (s *Server) handleJobs(w http.ResponseWriter, r *http.Request) {
 	sendJSONResponse(w, response, http.StatusCreated)
 }
 
-func (s *Server) handleJobById(w http.ResponseWriter, r *http.Request) {
-    // Only support GET method for now
-    if r.Method != http.MethodGet {
-        sendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
-        return
-    }
-    
-    // Extract job ID from URL
-    parts := strings.Split(r.URL.Path, "/")
-    if len(parts) < 3 {
-        sendErrorResponse(w, "Invalid job ID", http.StatusBadRequest)
-        return
-    }
-    
-    jobID := parts[len(parts)-1]
-    
-    // TODO in future: filter results by job ID
-    fmt.Printf("Getting results for job: %s\n", jobID)
-    
-    results, err := s.Coordinator.GetJobResults(jobID)
-    if err != nil {
-        sendErrorResponse(w, fmt.Sprintf("Error: %v", err), http.StatusNotFound)
-        return
-    }
-    
-    sendJSONResponse(w, results, http.StatusOK)
-}
-
-func (s *Server) handleWorkers(w http.ResponseWriter, r *http.Request) {
-	// Only support POST method for worker registration
-	if r.Method != http.MethodPost {
-		sendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+// handleListJobs returns a page of jobs. Submitters see their tenant's jobs, or
+// only their own when they have no tenant; admins see everyone's.
+func (s *Server) handleListJobs(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := node.JobFilter{
+		State:  node.JobState(query.Get("state")),
+		Owner:  query.Get("owner"),
+		Tenant: query.Get("tenant"),
+		Sort:   query.Get("sort"),
+	}
+	
+	if principal := requestPrincipal(r); principal.Role != RoleAdmin {
+		if principal.Tenant != "" {
+			filter.Tenant = principal.Tenant
+		} else {
+			filter.Owner = principal.Name
+		}
+	}
+	
+	var err error
+	for name, target := range map[string]*int{
+		"rangeStart": &filter.RangeStart,
+		"rangeEnd":   &filter.RangeEnd,
+		"offset":     &filter.Offset,
+		"limit":      &filter.Limit,
+	} {
+		if value := query.Get(name); value != "" {
+			if *target, err = strconv.Atoi(value); err != nil || *target < 0 {
+				sendErrorResponse(w, fmt.Sprintf("Invalid %s: %s", name, value), http.StatusBadRequest)
+				return
+			}
+		}
+	}
+	
+	for name, target := range map[string]*time.Time{
+		"createdAfter":  &filter.CreatedAfter,
+		"createdBefore": &filter.CreatedBefore,
+	} {
+		if value := query.Get(name); value != "" {
+			if *target, err = time.Parse(time.RFC3339, value); err != nil {
+				sendErrorResponse(w, fmt.Sprintf("Invalid %s (expected RFC 3339): %s", name, value), http.StatusBadRequest)
+				return
+			}
+		}
+	}
+	
+	page, err := s.Coordinator.ListJobs(filter)
+	if err != nil {
+		sendErrorResponse(w, fmt.Sprintf("Error: %v", err), http.StatusBadRequest)
 		return
 	}
 	
-	workerID := fmt.Sprintf("worker-%d", time.Now().UnixNano())
+	response := JobListResponse{
+		Jobs:   page.Jobs,
+		Total:  page.Total,
+		Offset: page.Offset,
+		Limit:  page.Limit,
+	}
+	sendJSONResponse(w, response, http.StatusOK)
+}
+
+// handleJobResults returns the primes found so far
+func (s *Server) handleJobResults(w http.ResponseWriter, r *http.Request, jobID string) {
+	slog.Debug("Getting results for job", "job_id", jobID)
+	
+	results, err := s.Coordinator.GetJobResults(jobID)
+	if err != nil {
+		sendErrorResponse(w, fmt.Sprintf("Error: %v", err), http.StatusNotFound)
+		return
+	}
 	
-	s.Coordinator.RegisterWorker(workerID)
+	sendJSONResponse(w, results, http.StatusOK)
+}
 
-	response := map[string]string{"workerId": workerID}
-	sendJSONResponse(w, response, http.StatusCreated)
+// handleJobStatus returns the job's lifecycle state and progress
+func (s *Server) handleJobStatus(w http.ResponseWriter, r *http.Request, jobID string) {
+	summary, err := s.Coordinator.JobSummary(jobID)
+	if err != nil {
+		sendErrorResponse(w, fmt.Sprintf("Error: %v", err), http.StatusNotFound)
+		return
+	}
+	
+	sendJSONResponse(w, summary, http.StatusOK)
 }
 
-func (s *Server) handleWorkerById(w http.ResponseWriter, r *http.Request) {
+// handleCancelJob stops a job from handing out any more chunks
+func (s *Server) handleCancelJob(w http.ResponseWriter, r *http.Request, jobID string) {
+	s.changeJobState(w, r, jobID, s.Coordinator.CancelJob(jobID))
+}
 
-	parts := strings.Split(r.URL.Path, "/")
+// handlePauseJob takes a job out of scheduling until it is resumed
+func (s *Server) handlePauseJob(w http.ResponseWriter, r *http.Request, jobID string) {
+	var req PauseJobRequest
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&req); err != nil && err != io.EOF {
+		sendErrorResponse(w, "Invalid request format", http.StatusBadRequest)
+		return
+	}
+	
+	s.changeJobState(w, r, jobID, s.Coordinator.PauseJob(jobID, req.Recall))
+}
+
+// handleResumeJob puts a paused job back in scheduling
+func (s *Server) handleResumeJob(w http.ResponseWriter, r *http.Request, jobID string) {
+	s.changeJobState(w, r, jobID, s.Coordinator.ResumeJob(jobID))
+}
 
-	if len(parts) < 3 {
-		sendErrorResponse(w, "Invalid worker ID", http.StatusBadRequest)
+// changeJobState reports the outcome of a lifecycle operation, returning the
+// job's new status on success
+func (s *Server) changeJobState(w http.ResponseWriter, r *http.Request, jobID string, err error) {
+	if err != nil {
+		status := http.StatusNotFound
+		if errors.Is(err, node.ErrInvalidTransition) {
+			status = http.StatusConflict
+		}
+		sendErrorResponse(w, fmt.Sprintf("Error: %v", err), status)
 		return
 	}
 	
-	var workerID string
-    for i, part := range parts {
-        if part == "workers" && i+1 < len(parts) {
-            workerID = parts[i+1]
-            break
-        }
-    }
+	s.handleJobStatus(w, r, jobID)
+}
 
-	_, exists := s.Coordinator.Workers[workerID]
-    if !exists {
-        sendErrorResponse(w, fmt.Sprintf("Worker not found: %s", workerID), http.StatusBadRequest)
-        return
-    }
+// handleUpdateJob changes the scheduling settings of an existing job
+func (s *Server) handleUpdateJob(w http.ResponseWriter, r *http.Request, jobID string) {
+	var req UpdateJobRequest
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&req); err != nil {
+		sendErrorResponse(w, "Invalid request format", http.StatusBadRequest)
+		return
+	}
+	
+	if req.Priority == nil {
+		sendErrorResponse(w, "Nothing to update", http.StatusBadRequest)
+		return
+	}
+	
+	if *req.Priority < node.MinPriority || *req.Priority > node.MaxPriority {
+		sendErrorResponse(w, fmt.Sprintf("Priority must be between %d and %d", node.MinPriority, node.MaxPriority), http.StatusBadRequest)
+		return
+	}
+	
+	if err := s.Coordinator.SetJobPriority(jobID, *req.Priority); err != nil {
+		sendErrorResponse(w, fmt.Sprintf("Error: %v", err), http.StatusNotFound)
+		return
+	}
+	
+	response := map[string]interface{}{"jobId": jobID, "priority": *req.Priority}
+	sendJSONResponse(w, response, http.StatusOK)
+}
 
-	if strings.HasSuffix(r.URL.Path, "/chunks") {
-		s.handleGetNextChunk(w, r, workerID)
-	} else if strings.Contains(r.URL.Path, "/results") {
-		s.handleSubmitResults(w, r, workerID)
-	} else {
-		sendErrorResponse(w, "Method not allowed or invalid endpoint", http.StatusMethodNotAllowed)
+// handleJobManifest returns the job's chunk digests and Merkle root, or the inclusion
+// proof of a single chunk when the chunk query parameter is given
+func (s *Server) handleJobManifest(w http.ResponseWriter, r *http.Request, jobID string) {
+	if chunkID := r.URL.Query().Get("chunk"); chunkID != "" {
+		proof, err := s.Coordinator.JobChunkProof(jobID, chunkID)
+		if err != nil {
+			sendErrorResponse(w, fmt.Sprintf("Error: %v", err), http.StatusNotFound)
+			return
+		}
+		
+		sendJSONResponse(w, proof, http.StatusOK)
+		return
 	}
+	
+	manifest, err := s.Coordinator.JobManifest(jobID)
+	if err != nil {
+		sendErrorResponse(w, fmt.Sprintf("Error: %v", err), http.StatusNotFound)
+		return
+	}
+	
+	sendJSONResponse(w, manifest, http.StatusOK)
 }
 
-func (s *Server) handleGetNextChunk(w http.ResponseWriter, r *http.Request, workerID string) {
+// handleListWorkers returns every worker in the pool
+func (s *Server) handleListWorkers(w http.ResponseWriter, r *http.Request) {
+	sendJSONResponse(w, WorkerListResponse{Workers: s.Coordinator.ListWorkers()}, http.StatusOK)
+}
 
-	if r.Method != http.MethodGet {
-		sendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
+// handleRegisterWorker adds the calling worker to the pool
+func (s *Server) handleRegisterWorker(w http.ResponseWriter, r *http.Request) {
+	// Older workers register without a body
+	var req RegisterWorkerRequest
+	decoder := json.NewDecoder(r.Body)
+	if err := decoder.Decode(&req); err != nil && err != io.EOF {
+		sendErrorResponse(w, "Invalid request format", http.StatusBadRequest)
 		return
 	}
 	
-	chunk, err := s.Coordinator.GetNextChunk(workerID)
+	// Workers with a client certificate keep the same ID across restarts
+	principal := requestPrincipal(r)
+	workerID := fmt.Sprintf("worker-%d", time.Now().UnixNano())
+	if principal.FromCertificate {
+		workerID = principal.Name
+	}
+	
+	s.Coordinator.RegisterWorker(workerID, req.Version, principal.Name)
+
+	response := map[string]string{"workerId": workerID}
+	sendJSONResponse(w, response, http.StatusCreated)
+}
+
+// handleGetWorker returns a worker's state and statistics
+func (s *Server) handleGetWorker(w http.ResponseWriter, r *http.Request, workerID string) {
+	summary, err := s.Coordinator.WorkerSummary(workerID)
+	if err != nil {
+		sendErrorResponse(w, fmt.Sprintf("Error: %v", err), http.StatusNotFound)
+		return
+	}
+	sendJSONResponse(w, summary, http.StatusOK)
+}
+
+// handleDrainWorker lets a worker finish its chunks, after which it exits
+func (s *Server) handleDrainWorker(w http.ResponseWriter, r *http.Request, workerID string) {
+	if err := s.Coordinator.DrainWorker(workerID); err != nil {
+		sendErrorResponse(w, fmt.Sprintf("Error: %v", err), http.StatusNotFound)
+		return
+	}
+	
+	s.handleGetWorker(w, r, workerID)
+}
+
+// handleEvictWorker removes a worker, reassigning its chunks immediately
+func (s *Server) handleEvictWorker(w http.ResponseWriter, r *http.Request, workerID string) {
+	if err := s.Coordinator.EvictWorker(workerID); err != nil {
+		sendErrorResponse(w, fmt.Sprintf("Error: %v", err), http.StatusNotFound)
+		return
+	}
+	sendJSONResponse(w, map[string]string{"workerId": workerID, "state": "evicted"}, http.StatusOK)
+}
+
+func (s *Server) handleGetNextChunk(w http.ResponseWriter, r *http.Request, workerID string) {
+	chunk, err := s.Coordinator.GetNextChunk(r.Context(), workerID)
+	if errors.Is(err, node.ErrWorkerDraining) {
+		// Tells the worker to exit once it has delivered its results
+		sendErrorResponse(w, fmt.Sprintf("Error: %v", err), http.StatusGone)
+		return
+	}
 	if err != nil {
 		sendErrorResponse(w, fmt.Sprintf("Error: %v", err), http.StatusBadRequest)
 		return
@@ -190,11 +404,6 @@ func (s *Server) handleGetNextChunk(w http.ResponseWriter, r *http.Request, work
 }
 
 func (s *Server) handleSubmitResults(w http.ResponseWriter, r *http.Request, workerID string) {
-	if r.Method != http.MethodPost {
-		sendErrorResponse(w, "Method not allowed", http.StatusMethodNotAllowed)
-		return
-	}
-	
 	var result node.ChunkResult
 	decoder := json.NewDecoder(r.Body)
 	if err := decoder.Decode(&result); err != nil {
@@ -202,7 +411,22 @@ func (s *Server) handleSubmitResults(w http.ResponseWriter, r *http.Request, wor
 		return
 	}
 	
-	err := s.Coordinator.SubmitResult(result)
+	// The worker in the path is the submitter, regardless of what the body claims
+	result.WorkerID = workerID
+	
+	err := s.Coordinator.SubmitResult(r.Context(), result)
+	if errors.Is(err, node.ErrStaleAssignment) {
+		sendErrorResponse(w, fmt.Sprintf("Error: %v", err), http.StatusConflict)
+		return
+	}
+	if errors.Is(err, node.ErrDigestMismatch) {
+		sendErrorResponse(w, fmt.Sprintf("Error: %v", err), http.StatusUnprocessableEntity)
+		return
+	}
+	if errors.Is(err, node.ErrUnknownAssignment) {
+		sendErrorResponse(w, fmt.Sprintf("Error: %v", err), http.StatusForbidden)
+		return
+	}
 	if err != nil {
 		sendErrorResponse(w, fmt.Sprintf("Error: %v", err), http.StatusInternalServerError)
 		return
@@ -217,12 +441,22 @@ func sendJSONResponse(w http.ResponseWriter, data interface{}, statusCode int) {
 	w.WriteHeader(statusCode)
 	
 	if err := json.NewEncoder(w).Encode(data); err != nil {
-		fmt.Printf("Error encoding JSON response: %v\n", err)
+		slog.Error("Failed to encode JSON response", "error", err)
 	}
 }
 
-// Helper to send error responses
+// Helper to send error responses as problem details
 func sendErrorResponse(w http.ResponseWriter, message string, statusCode int) {
-	response := ErrorResponse{Error: message}
-	sendJSONResponse(w, response, statusCode)
+	response := Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(statusCode),
+		Status: statusCode,
+		Detail: message,
+	}
+	
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(statusCode)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		slog.Error("Failed to encode problem response", "error", err)
+	}
 }
\ No newline at end of file
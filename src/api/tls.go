@@ -0,0 +1,60 @@
+// TLS support for the API server. When a certificate is configured the server only
+// speaks HTTPS, and when a client CA bundle is configured it also verifies client
+// certificates. A verified client certificate authenticates its holder as a worker
+// whose ID is the certificate's common name.
+
+package api
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// tlsConfig builds the server's TLS configuration. Client certificates are
+// optional at the TLS layer so job submitters can still connect with an API key;
+// worker endpoints then require either a verified certificate or a worker key.
+func (s *Server) tlsConfig() (*tls.Config, error) {
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	if s.ClientCAFile != "" {
+		pool, err := loadCertPool(s.ClientCAFile)
+		if err != nil {
+			return nil, err
+		}
+		config.ClientCAs = pool
+		config.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+
+	return config, nil
+}
+
+// certificatePrincipal returns the worker identity of a verified client certificate
+func certificatePrincipal(state *tls.ConnectionState) (*Principal, bool) {
+	if state == nil || len(state.VerifiedChains) == 0 || len(state.VerifiedChains[0]) == 0 {
+		return nil, false
+	}
+
+	name := state.VerifiedChains[0][0].Subject.CommonName
+	if name == "" || strings.ContainsAny(name, "/?#% ") {
+		return nil, false
+	}
+
+	return &Principal{Name: name, Role: RoleWorker, FromCertificate: true}, true
+}
+
+func loadCertPool(path string) (*x509.CertPool, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA bundle - %v", err)
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return nil, fmt.Errorf("no certificates found in CA bundle %s", path)
+	}
+
+	return pool, nil
+}
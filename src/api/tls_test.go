@@ -0,0 +1,240 @@
+package api
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"distributed-prime-number-generator/src/node"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testCA issues certificates for TLS tests
+type testCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	pem  []byte
+}
+
+func newTestCA(t *testing.T) *testCA {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate CA key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("failed to create CA certificate: %v", err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatalf("failed to parse CA certificate: %v", err)
+	}
+
+	return &testCA{cert: cert, key: key, pem: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})}
+}
+
+// issue returns a certificate for the common name, usable by servers on localhost
+// and by clients
+func (ca *testCA) issue(t *testing.T, commonName string) tls.Certificate {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: commonName},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatalf("failed to create certificate: %v", err)
+	}
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+// newTLSTestServer starts the API over HTTPS, verifying client certificates issued
+// by ca. It also accepts a submitter key and a worker key.
+func newTLSTestServer(t *testing.T, ca *testCA) *httptest.Server {
+	t.Helper()
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	if err := os.WriteFile(caFile, ca.pem, 0o600); err != nil {
+		t.Fatalf("failed to write CA bundle: %v", err)
+	}
+
+	server := NewServer(node.NewCoordinator(), 0)
+	server.ClientCAFile = caFile
+	server.Keys = map[string]*Principal{
+		"submitter-key": {Name: "alice", Role: RoleSubmitter},
+		"worker-key":    {Name: "pool", Role: RoleWorker},
+	}
+
+	config, err := server.tlsConfig()
+	if err != nil {
+		t.Fatalf("tlsConfig failed: %v", err)
+	}
+	config.Certificates = []tls.Certificate{ca.issue(t, "server")}
+
+	ts := httptest.NewUnstartedServer(server.Handler())
+	ts.TLS = config
+	ts.StartTLS()
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+// tlsClient trusts ca and presents the given client certificates
+func tlsClient(ca *testCA, certs ...tls.Certificate) *http.Client {
+	pool := x509.NewCertPool()
+	pool.AppendCertsFromPEM(ca.pem)
+	return &http.Client{Transport: &http.Transport{TLSClientConfig: &tls.Config{
+		RootCAs:      pool,
+		Certificates: certs,
+	}}}
+}
+
+func registerWorker(t *testing.T, client *http.Client, url string) (string, int) {
+	t.Helper()
+
+	resp, err := client.Post(url+"/api/v1/workers", "application/json", strings.NewReader(`{"version":"test"}`))
+	if err != nil {
+		t.Fatalf("registration failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var body struct {
+		WorkerID string `json:"workerId"`
+	}
+	json.NewDecoder(resp.Body).Decode(&body)
+	return body.WorkerID, resp.StatusCode
+}
+
+func TestCertificateWorkersActOnlyAsThemselves(t *testing.T) {
+	ca := newTestCA(t)
+	ts := newTLSTestServer(t, ca)
+	workerA := tlsClient(ca, ca.issue(t, "worker-a"))
+	workerB := tlsClient(ca, ca.issue(t, "worker-b"))
+
+	for name, client := range map[string]*http.Client{"worker-a": workerA, "worker-b": workerB} {
+		id, status := registerWorker(t, client, ts.URL)
+		if status != http.StatusCreated || id != name {
+			t.Fatalf("registration returned %d with ID %q, want %d with %q", status, id, http.StatusCreated, name)
+		}
+	}
+
+	tests := []struct {
+		name   string
+		client *http.Client
+		key    string
+		path   string
+		want   int
+	}{
+		{"own chunks", workerA, "", "/api/v1/workers/worker-a/chunks", http.StatusNoContent},
+		{"another worker's chunks", workerB, "", "/api/v1/workers/worker-a/chunks", http.StatusForbidden},
+		{"unregistered worker", workerA, "", "/api/v1/workers/worker-c/chunks", http.StatusForbidden},
+		{"worker key", tlsClient(ca), "worker-key", "/api/v1/workers/worker-a/chunks", http.StatusForbidden},
+		{"submitter key", tlsClient(ca), "submitter-key", "/api/v1/workers/worker-a/chunks", http.StatusForbidden},
+		{"no credentials", tlsClient(ca), "", "/api/v1/workers/worker-a/chunks", http.StatusUnauthorized},
+		{"admin endpoint", workerA, "", "/api/v1/workers/worker-a", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(http.MethodGet, ts.URL+tt.path, nil)
+			if err != nil {
+				t.Fatalf("failed to build request: %v", err)
+			}
+			if tt.key != "" {
+				req.Header.Set("X-API-Key", tt.key)
+			}
+			resp, err := tt.client.Do(req)
+			if err != nil {
+				t.Fatalf("request failed: %v", err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.want {
+				t.Errorf("GET %s returned %d, want %d", tt.path, resp.StatusCode, tt.want)
+			}
+		})
+	}
+}
+
+func TestUntrustedClientCertificatesAreRejected(t *testing.T) {
+	trusted := newTestCA(t)
+	other := newTestCA(t)
+	ts := newTLSTestServer(t, trusted)
+
+	// The server's certificate is trusted, the client's is from another CA
+	client := tlsClient(trusted, other.issue(t, "worker-a"))
+	resp, err := client.Post(ts.URL+"/api/v1/workers", "application/json", strings.NewReader(`{}`))
+	if err == nil {
+		resp.Body.Close()
+		t.Errorf("registration with an untrusted certificate returned %d, want a failed handshake", resp.StatusCode)
+	}
+}
+
+func TestCertificatePrincipal(t *testing.T) {
+	ca := newTestCA(t)
+	chain := func(commonName string) *tls.ConnectionState {
+		cert, err := x509.ParseCertificate(ca.issue(t, commonName).Certificate[0])
+		if err != nil {
+			t.Fatalf("failed to parse certificate: %v", err)
+		}
+		return &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{cert, ca.cert}}}
+	}
+
+	tests := []struct {
+		name  string
+		state *tls.ConnectionState
+		want  string
+		ok    bool
+	}{
+		{"no TLS", nil, "", false},
+		{"unverified", &tls.ConnectionState{}, "", false},
+		{"worker", chain("worker-a"), "worker-a", true},
+		{"empty name", chain(""), "", false},
+		{"name unusable in paths", chain("worker/a"), "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			principal, ok := certificatePrincipal(tt.state)
+			if ok != tt.ok {
+				t.Fatalf("certificatePrincipal returned ok %v, want %v", ok, tt.ok)
+			}
+			if !ok {
+				return
+			}
+			if principal.Name != tt.want || principal.Role != RoleWorker || !principal.FromCertificate {
+				t.Errorf("certificatePrincipal returned %+v, want certificate worker %s", principal, tt.want)
+			}
+		})
+	}
+}
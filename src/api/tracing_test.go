@@ -0,0 +1,67 @@
+package api
+
+import (
+	"distributed-prime-number-generator/src/node"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestChunkSpansJoinTheJobsTrace(t *testing.T) {
+	exporter := tracetest.NewInMemoryExporter()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	otel.SetTracerProvider(provider)
+	t.Cleanup(func() { provider.Shutdown(t.Context()) })
+
+	ts := httptest.NewServer(NewServer(node.NewCoordinator(), 0).Handler())
+	t.Cleanup(ts.Close)
+
+	resp, err := http.Post(ts.URL+"/api/v1/jobs", "application/json", strings.NewReader(`{"start":2,"end":1000,"chunkSize":1000}`))
+	if err != nil {
+		t.Fatalf("CreateJob request failed: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("CreateJob returned %d, want %d", resp.StatusCode, http.StatusCreated)
+	}
+
+	worker := node.NewWorker(ts.URL, t.TempDir())
+	if err := worker.Register(); err != nil {
+		t.Fatalf("Register failed: %v", err)
+	}
+	chunk, err := worker.GetNextChunk()
+	if err != nil || chunk == nil {
+		t.Fatalf("GetNextChunk returned %v, %v, want a chunk", chunk, err)
+	}
+	result, err := worker.ProcessChunk(chunk)
+	if err != nil {
+		t.Fatalf("ProcessChunk failed: %v", err)
+	}
+	if err := worker.SubmitResult(*result); err != nil {
+		t.Fatalf("SubmitResult failed: %v", err)
+	}
+
+	spans := map[string]tracetest.SpanStub{}
+	for _, span := range exporter.GetSpans() {
+		spans[span.Name] = span
+	}
+	job, ok := spans["Coordinator.CreateJob"]
+	if !ok {
+		t.Fatalf("no Coordinator.CreateJob span among %d spans", len(spans))
+	}
+
+	for _, name := range []string{"lease chunk", "Worker.ProcessChunk", "Worker.SubmitResult", "Coordinator.SubmitResult"} {
+		span, ok := spans[name]
+		if !ok {
+			t.Errorf("no %s span recorded", name)
+			continue
+		}
+		if span.SpanContext.TraceID() != job.SpanContext.TraceID() {
+			t.Errorf("%s span is in trace %s, want the job's trace %s", name, span.SpanContext.TraceID(), job.SpanContext.TraceID())
+		}
+	}
+}
@@ -0,0 +1,341 @@
+// Go client SDK for the server's REST API. Wraps job submission, lifecycle control,
+// result retrieval and worker management in context-aware methods that use the same
+// request and response types as the server, so services and tools such as primectl
+// don't have to assemble requests by hand. Failed calls return an *Error.
+
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultPollInterval  = 2 * time.Second  // between status checks while waiting for a job
+	DefaultHeaderTimeout = 60 * time.Second // for the server to start answering a request
+)
+
+type Client struct {
+	BaseURL    string // for example http://localhost:8080
+	APIKey     string // sent as X-API-Key when set
+	HTTPClient *http.Client
+}
+
+// New creates a client for the server at baseURL. Requests have no overall timeout,
+// since results can take a while to download; use the context to bound them.
+func New(baseURL, apiKey string) *Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ResponseHeaderTimeout = DefaultHeaderTimeout
+
+	return &Client{
+		BaseURL:    strings.TrimSuffix(baseURL, "/"),
+		APIKey:     apiKey,
+		HTTPClient: &http.Client{Transport: transport},
+	}
+}
+
+// UseTLS verifies the server against a CA bundle instead of the system roots
+func (c *Client) UseTLS(caFile string) error {
+	data, err := os.ReadFile(caFile)
+	if err != nil {
+		return fmt.Errorf("failed to read CA bundle - %v", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(data) {
+		return fmt.Errorf("no certificates found in CA bundle %s", caFile)
+	}
+
+	transport, ok := c.HTTPClient.Transport.(*http.Transport)
+	if !ok {
+		return fmt.Errorf("client transport is not an *http.Transport")
+	}
+	transport.TLSClientConfig = &tls.Config{MinVersion: tls.VersionTLS12, RootCAs: pool}
+	return nil
+}
+
+// ListJobsOptions filters, sorts and pages ListJobs. Zero values are left to the
+// server's defaults.
+type ListJobsOptions struct {
+	State         JobState
+	Owner         string
+	Tenant        string
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	RangeStart    int // jobs whose range overlaps [RangeStart, RangeEnd]
+	RangeEnd      int
+	Sort          string
+	Offset        int
+	Limit         int
+}
+
+// CreateJob submits a job and returns its ID
+func (c *Client) CreateJob(ctx context.Context, req CreateJobRequest) (string, error) {
+	var response JobResponse
+	if err := c.do(ctx, http.MethodPost, "/api/v1/jobs", req, &response); err != nil {
+		return "", err
+	}
+	return response.JobID, nil
+}
+
+// ListJobs returns a page of the jobs visible to the caller
+func (c *Client) ListJobs(ctx context.Context, options ListJobsOptions) (*JobListResponse, error) {
+	query := url.Values{}
+	if options.State != "" {
+		query.Set("state", string(options.State))
+	}
+	if options.Owner != "" {
+		query.Set("owner", options.Owner)
+	}
+	if options.Tenant != "" {
+		query.Set("tenant", options.Tenant)
+	}
+	if !options.CreatedAfter.IsZero() {
+		query.Set("createdAfter", options.CreatedAfter.Format(time.RFC3339))
+	}
+	if !options.CreatedBefore.IsZero() {
+		query.Set("createdBefore", options.CreatedBefore.Format(time.RFC3339))
+	}
+	if options.RangeStart > 0 {
+		query.Set("rangeStart", strconv.Itoa(options.RangeStart))
+	}
+	if options.RangeEnd > 0 {
+		query.Set("rangeEnd", strconv.Itoa(options.RangeEnd))
+	}
+	if options.Sort != "" {
+		query.Set("sort", options.Sort)
+	}
+	if options.Offset > 0 {
+		query.Set("offset", strconv.Itoa(options.Offset))
+	}
+	if options.Limit > 0 {
+		query.Set("limit", strconv.Itoa(options.Limit))
+	}
+
+	path := "/api/v1/jobs"
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+
+	var response JobListResponse
+	if err := c.do(ctx, http.MethodGet, path, nil, &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// JobStatus returns a job's lifecycle state and progress
+func (c *Client) JobStatus(ctx context.Context, jobID string) (*JobSummary, error) {
+	return c.jobSummary(ctx, http.MethodGet, jobPath(jobID, "status"), nil)
+}
+
+// UpdateJob changes a job's scheduling settings
+func (c *Client) UpdateJob(ctx context.Context, jobID string, req UpdateJobRequest) error {
+	return c.do(ctx, http.MethodPatch, jobPath(jobID, ""), req, nil)
+}
+
+// CancelJob stops a job from handing out any more chunks
+func (c *Client) CancelJob(ctx context.Context, jobID string) (*JobSummary, error) {
+	return c.jobSummary(ctx, http.MethodPost, jobPath(jobID, "cancel"), nil)
+}
+
+// PauseJob takes a job out of scheduling, and with recall also takes back the
+// chunks workers are computing
+func (c *Client) PauseJob(ctx context.Context, jobID string, recall bool) (*JobSummary, error) {
+	return c.jobSummary(ctx, http.MethodPost, jobPath(jobID, "pause"), PauseJobRequest{Recall: recall})
+}
+
+// ResumeJob puts a paused job back in scheduling
+func (c *Client) ResumeJob(ctx context.Context, jobID string) (*JobSummary, error) {
+	return c.jobSummary(ctx, http.MethodPost, jobPath(jobID, "resume"), nil)
+}
+
+// JobResults returns the primes found so far for a job. Use Results to process
+// large jobs without holding every prime in memory.
+func (c *Client) JobResults(ctx context.Context, jobID string) ([]int, error) {
+	var primes []int
+	if err := c.do(ctx, http.MethodGet, jobPath(jobID, ""), nil, &primes); err != nil {
+		return nil, err
+	}
+	return primes, nil
+}
+
+// JobManifest returns a job's chunk digests and Merkle root
+func (c *Client) JobManifest(ctx context.Context, jobID string) (*Manifest, error) {
+	var manifest Manifest
+	if err := c.do(ctx, http.MethodGet, jobPath(jobID, "manifest"), nil, &manifest); err != nil {
+		return nil, err
+	}
+	return &manifest, nil
+}
+
+// JobChunkProof returns the Merkle proof linking one chunk's digest to the root of
+// its job's manifest
+func (c *Client) JobChunkProof(ctx context.Context, jobID, chunkID string) (*ChunkProof, error) {
+	var proof ChunkProof
+	path := jobPath(jobID, "manifest") + "?chunk=" + url.QueryEscape(chunkID)
+	if err := c.do(ctx, http.MethodGet, path, nil, &proof); err != nil {
+		return nil, err
+	}
+	return &proof, nil
+}
+
+// WaitOptions controls WaitForJob
+type WaitOptions struct {
+	Interval   time.Duration            // between status checks, DefaultPollInterval when 0
+	OnProgress func(summary JobSummary) // called with every status checked, including the last
+}
+
+// WaitForJob polls a job's status until it reaches a final state and returns that
+// final status. A job that fails or is cancelled is returned together with an
+// error matching ErrJobNotCompleted. It gives up when the context is done.
+func (c *Client) WaitForJob(ctx context.Context, jobID string, options WaitOptions) (*JobSummary, error) {
+	interval := options.Interval
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		summary, err := c.JobStatus(ctx, jobID)
+		if err != nil {
+			return nil, err
+		}
+		if options.OnProgress != nil {
+			options.OnProgress(*summary)
+		}
+
+		if summary.State.Terminal() {
+			if summary.State != JobCompleted {
+				return summary, jobNotCompleted(summary)
+			}
+			return summary, nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return summary, ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// ListWorkers returns every worker in the pool
+func (c *Client) ListWorkers(ctx context.Context) ([]WorkerSummary, error) {
+	var response WorkerListResponse
+	if err := c.do(ctx, http.MethodGet, "/api/v1/workers", nil, &response); err != nil {
+		return nil, err
+	}
+	return response.Workers, nil
+}
+
+// GetWorker returns a worker's state, activity and throughput
+func (c *Client) GetWorker(ctx context.Context, workerID string) (*WorkerSummary, error) {
+	var summary WorkerSummary
+	if err := c.do(ctx, http.MethodGet, "/api/v1/workers/"+url.PathEscape(workerID), nil, &summary); err != nil {
+		return nil, err
+	}
+	return &summary, nil
+}
+
+// DrainWorker stops a worker from receiving new chunks once its current ones are
+// done
+func (c *Client) DrainWorker(ctx context.Context, workerID string) (*WorkerSummary, error) {
+	var summary WorkerSummary
+	if err := c.do(ctx, http.MethodPost, "/api/v1/workers/"+url.PathEscape(workerID)+"/drain", nil, &summary); err != nil {
+		return nil, err
+	}
+	return &summary, nil
+}
+
+// EvictWorker removes a worker from the pool immediately
+func (c *Client) EvictWorker(ctx context.Context, workerID string) error {
+	return c.do(ctx, http.MethodPost, "/api/v1/workers/"+url.PathEscape(workerID)+"/evict", nil, nil)
+}
+
+func (c *Client) jobSummary(ctx context.Context, method, path string, body interface{}) (*JobSummary, error) {
+	var summary JobSummary
+	if err := c.do(ctx, method, path, body, &summary); err != nil {
+		return nil, err
+	}
+	return &summary, nil
+}
+
+func jobPath(jobID, action string) string {
+	path := "/api/v1/jobs/" + url.PathEscape(jobID)
+	if action != "" {
+		path += "/" + action
+	}
+	return path
+}
+
+// do sends a request with an optional JSON body and decodes a JSON response into
+// out when it isn't nil
+func (c *Client) do(ctx context.Context, method, path string, body, out interface{}) error {
+	resp, err := c.send(ctx, method, path, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response - %v", err)
+	}
+	return nil
+}
+
+// send makes a request and returns the response when it succeeded, leaving the
+// caller to read and close the body. Error responses are returned as an *Error.
+func (c *Client) send(ctx context.Context, method, path string, body interface{}) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode request - %v", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.BaseURL+path, reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request - %v", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.APIKey != "" {
+		req.Header.Set("X-API-Key", c.APIKey)
+	}
+
+	resp, err := c.HTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request - %v", err)
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+
+		apiErr := &Error{Method: method, Path: path, StatusCode: resp.StatusCode}
+		var problem Problem
+		if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&problem); err == nil {
+			apiErr.Message = problem.Detail
+		}
+		return nil, apiErr
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,342 @@
+package client
+
+import (
+	"context"
+	"distributed-prime-number-generator/src/api"
+	"distributed-prime-number-generator/src/node"
+	"errors"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+)
+
+// newTestClient returns a client for an open API server wrapping a real coordinator
+func newTestClient(t *testing.T) (*Client, *node.Coordinator, string) {
+	t.Helper()
+
+	coordinator := node.NewCoordinator()
+	ts := httptest.NewServer(api.NewServer(coordinator, 0).Handler())
+	t.Cleanup(ts.Close)
+	return New(ts.URL, ""), coordinator, ts.URL
+}
+
+// runWorker computes every chunk on offer with a real worker, until none is left
+func runWorker(serverURL, spoolDir string) error {
+	worker := node.NewWorker(serverURL, spoolDir)
+	if err := worker.Register(); err != nil {
+		return err
+	}
+	for {
+		chunk, err := worker.GetNextChunk()
+		if err != nil || chunk == nil {
+			return err
+		}
+		result, err := worker.ProcessChunk(chunk)
+		if err != nil {
+			return err
+		}
+		if err := worker.SubmitResult(*result); err != nil {
+			return err
+		}
+	}
+}
+
+// startWorker runs a worker in the background, failing the test if it fails
+func startWorker(t *testing.T, serverURL string) {
+	t.Helper()
+
+	done := make(chan error, 1)
+	spoolDir := t.TempDir()
+	go func() { done <- runWorker(serverURL, spoolDir) }()
+	t.Cleanup(func() {
+		if err := <-done; err != nil {
+			t.Errorf("worker failed: %v", err)
+		}
+	})
+}
+
+func TestCreateJob(t *testing.T) {
+	c, _, _ := newTestClient(t)
+	ctx := context.Background()
+
+	jobID, err := c.CreateJob(ctx, CreateJobRequest{Start: 2, End: 100, Priority: 3})
+	if err != nil {
+		t.Fatalf("CreateJob failed: %v", err)
+	}
+	summary, err := c.JobStatus(ctx, jobID)
+	if err != nil {
+		t.Fatalf("JobStatus failed: %v", err)
+	}
+	if summary.ID != jobID || summary.State != JobQueued || summary.Start != 2 || summary.End != 100 || summary.Priority != 3 {
+		t.Errorf("JobStatus returned %+v, want queued job %s over 2 to 100 with priority 3", summary, jobID)
+	}
+
+	page, err := c.ListJobs(ctx, ListJobsOptions{RangeStart: 50, RangeEnd: 60, CreatedAfter: time.Now().Add(-time.Minute)})
+	if err != nil {
+		t.Fatalf("ListJobs failed: %v", err)
+	}
+	if page.Total != 1 || page.Jobs[0].ID != jobID {
+		t.Errorf("ListJobs returned %+v, want job %s", page, jobID)
+	}
+	page, err = c.ListJobs(ctx, ListJobsOptions{RangeStart: 200})
+	if err != nil {
+		t.Fatalf("ListJobs failed: %v", err)
+	}
+	if page.Total != 0 {
+		t.Errorf("ListJobs past the job's range returned %d jobs, want none", page.Total)
+	}
+
+	priority := 7
+	if err := c.UpdateJob(ctx, jobID, UpdateJobRequest{Priority: &priority}); err != nil {
+		t.Fatalf("UpdateJob failed: %v", err)
+	}
+	if summary, err = c.JobStatus(ctx, jobID); err != nil || summary.Priority != priority {
+		t.Errorf("JobStatus after UpdateJob returned %+v, %v, want priority %d", summary, err, priority)
+	}
+}
+
+func TestWaitForJob(t *testing.T) {
+	c, _, serverURL := newTestClient(t)
+	ctx := context.Background()
+
+	jobID, err := c.CreateJob(ctx, CreateJobRequest{Start: 2, End: 1000, ChunkSize: 500})
+	if err != nil {
+		t.Fatalf("CreateJob failed: %v", err)
+	}
+	startWorker(t, serverURL)
+
+	var checks int
+	summary, err := c.WaitForJob(ctx, jobID, WaitOptions{
+		Interval:   10 * time.Millisecond,
+		OnProgress: func(JobSummary) { checks++ },
+	})
+	if err != nil {
+		t.Fatalf("WaitForJob failed: %v", err)
+	}
+	if summary.State != JobCompleted || summary.PrimesFound != 168 {
+		t.Errorf("WaitForJob returned %s with %d primes, want %s with 168", summary.State, summary.PrimesFound, JobCompleted)
+	}
+	if checks == 0 {
+		t.Errorf("OnProgress was never called")
+	}
+
+	// A job that ends any other way is reported as an error with its final status
+	jobID, err = c.CreateJob(ctx, CreateJobRequest{Start: 2, End: 1000})
+	if err != nil {
+		t.Fatalf("CreateJob failed: %v", err)
+	}
+	if _, err := c.CancelJob(ctx, jobID); err != nil {
+		t.Fatalf("CancelJob failed: %v", err)
+	}
+	summary, err = c.WaitForJob(ctx, jobID, WaitOptions{Interval: 10 * time.Millisecond})
+	if !errors.Is(err, ErrJobNotCompleted) {
+		t.Fatalf("WaitForJob returned %v, want %v", err, ErrJobNotCompleted)
+	}
+	if summary == nil || summary.State != JobCancelled {
+		t.Errorf("WaitForJob returned %+v, want the cancelled job's status", summary)
+	}
+}
+
+func TestResults(t *testing.T) {
+	c, _, serverURL := newTestClient(t)
+	ctx := context.Background()
+
+	jobID, err := c.CreateJob(ctx, CreateJobRequest{Start: 2, End: 30, ChunkSize: 10})
+	if err != nil {
+		t.Fatalf("CreateJob failed: %v", err)
+	}
+
+	// Before any chunk is done the server lists the results as null
+	var primes []int
+	for prime, err := range c.Results(ctx, jobID) {
+		if err != nil {
+			t.Fatalf("Results failed: %v", err)
+		}
+		primes = append(primes, prime)
+	}
+	if len(primes) != 0 {
+		t.Fatalf("Results of a new job returned %v, want none", primes)
+	}
+
+	if err := runWorker(serverURL, t.TempDir()); err != nil {
+		t.Fatalf("worker failed: %v", err)
+	}
+	for prime, err := range c.Results(ctx, jobID) {
+		if err != nil {
+			t.Fatalf("Results failed: %v", err)
+		}
+		primes = append(primes, prime)
+	}
+	slices.Sort(primes)
+	want := []int{2, 3, 5, 7, 11, 13, 17, 19, 23, 29}
+	if !slices.Equal(primes, want) {
+		t.Errorf("Results returned %v, want %v", primes, want)
+	}
+
+	// Stopping early ends the download without an error
+	for _, err := range c.Results(ctx, jobID) {
+		if err != nil {
+			t.Fatalf("Results failed: %v", err)
+		}
+		break
+	}
+
+	manifest, err := c.JobManifest(ctx, jobID)
+	if err != nil {
+		t.Fatalf("JobManifest failed: %v", err)
+	}
+	if !manifest.Complete || len(manifest.Chunks) == 0 {
+		t.Fatalf("JobManifest returned %+v, want a complete manifest", manifest)
+	}
+	proof, err := c.JobChunkProof(ctx, jobID, manifest.Chunks[0].ChunkID)
+	if err != nil {
+		t.Fatalf("JobChunkProof failed: %v", err)
+	}
+	if proof.Root != manifest.Root || proof.Digest != manifest.Chunks[0].Digest {
+		t.Errorf("JobChunkProof returned %+v, want a proof of chunk %s against root %s", proof, manifest.Chunks[0].ChunkID, manifest.Root)
+	}
+}
+
+func TestJobEvents(t *testing.T) {
+	c, _, serverURL := newTestClient(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	jobID, err := c.CreateJob(ctx, CreateJobRequest{Start: 2, End: 30, ChunkSize: 10})
+	if err != nil {
+		t.Fatalf("CreateJob failed: %v", err)
+	}
+
+	events := make(chan Event)
+	failed := make(chan error, 1)
+	go func() {
+		defer close(events)
+		for event, err := range c.JobEvents(ctx, jobID) {
+			if err != nil {
+				failed <- err
+				return
+			}
+			events <- event
+		}
+	}()
+
+	// Give the stream time to subscribe before there is anything to see
+	time.Sleep(50 * time.Millisecond)
+	startWorker(t, serverURL)
+
+	var last Event
+	for event := range events {
+		if event.JobID != jobID {
+			t.Errorf("event %+v is not about job %s", event, jobID)
+		}
+		last = event
+	}
+	select {
+	case err := <-failed:
+		t.Fatalf("JobEvents failed: %v", err)
+	default:
+	}
+	if last.Type != EventJobCompleted || last.State != JobCompleted {
+		t.Errorf("stream ended with %+v, want the job's completion", last)
+	}
+}
+
+func TestJobEventsOfFinishedJob(t *testing.T) {
+	c, _, _ := newTestClient(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	jobID, err := c.CreateJob(ctx, CreateJobRequest{Start: 2, End: 30})
+	if err != nil {
+		t.Fatalf("CreateJob failed: %v", err)
+	}
+	if _, err := c.CancelJob(ctx, jobID); err != nil {
+		t.Fatalf("CancelJob failed: %v", err)
+	}
+
+	// The stream replays the event that finished the job and ends
+	var events []Event
+	for event, err := range c.JobEvents(ctx, jobID) {
+		if err != nil {
+			t.Fatalf("JobEvents failed: %v", err)
+		}
+		events = append(events, event)
+	}
+	if len(events) != 1 || events[0].Type != EventJobStateChanged || events[0].State != JobCancelled || events[0].JobID != jobID {
+		t.Errorf("JobEvents returned %+v, want the job's cancellation", events)
+	}
+
+	for _, err := range c.JobEvents(ctx, "no-such-job") {
+		if !errors.Is(err, ErrNotFound) {
+			t.Errorf("JobEvents of an unknown job returned %v, want %v", err, ErrNotFound)
+		}
+	}
+}
+
+func TestGetWorker(t *testing.T) {
+	c, coordinator, _ := newTestClient(t)
+	coordinator.RegisterWorker("worker-a", "test", "")
+
+	summary, err := c.GetWorker(context.Background(), "worker-a")
+	if err != nil {
+		t.Fatalf("GetWorker failed: %v", err)
+	}
+	if summary.ID != "worker-a" || summary.Version != "test" || summary.State != WorkerActive {
+		t.Errorf("GetWorker returned %+v, want active worker-a at version test", summary)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	c, coordinator, _ := newTestClient(t)
+	ctx := context.Background()
+
+	coordinator.SetTenantQuota("", node.TenantQuota{MaxConcurrentJobs: 1})
+	jobID, err := c.CreateJob(ctx, CreateJobRequest{Start: 2, End: 100})
+	if err != nil {
+		t.Fatalf("CreateJob failed: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		call   func() error
+		want   error
+		status int
+	}{
+		{"unknown job", func() error {
+			_, err := c.JobStatus(ctx, "no-such-job")
+			return err
+		}, ErrNotFound, 404},
+		{"unknown worker", func() error {
+			_, err := c.GetWorker(ctx, "no-such-worker")
+			return err
+		}, ErrNotFound, 404},
+		{"invalid transition", func() error {
+			_, err := c.ResumeJob(ctx, jobID)
+			return err
+		}, ErrConflict, 409},
+		{"quota exceeded", func() error {
+			_, err := c.CreateJob(ctx, CreateJobRequest{Start: 2, End: 100})
+			return err
+		}, ErrTooManyRequests, 429},
+		{"invalid request", func() error {
+			_, err := c.CreateJob(ctx, CreateJobRequest{Start: 100, End: 2})
+			return err
+		}, ErrBadRequest, 400},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.call()
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("call returned %v, want %v", err, tt.want)
+			}
+			var apiErr *Error
+			if !errors.As(err, &apiErr) || apiErr.Message == "" {
+				t.Errorf("error %v doesn't carry the server's problem detail", err)
+			}
+			if status := StatusCode(err); status != tt.status {
+				t.Errorf("StatusCode returned %d, want %d", status, tt.status)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+// Typed errors for failed API calls. The server's problem details are turned into
+// an *Error carrying the status code and message, which also matches the sentinel
+// error for its status with errors.Is, so callers can write
+//
+//	if errors.Is(err, client.ErrNotFound) { ... }
+
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+var (
+	ErrBadRequest       = errors.New("bad request")
+	ErrUnauthorized     = errors.New("missing or invalid API key")
+	ErrForbidden        = errors.New("forbidden")
+	ErrNotFound         = errors.New("not found")
+	ErrMethodNotAllowed = errors.New("method not allowed")
+	ErrConflict         = errors.New("conflict")          // for example an invalid job state transition
+	ErrGone             = errors.New("gone")              // a draining worker asking for work
+	ErrTooManyRequests  = errors.New("too many requests") // a tenant quota was exceeded
+	ErrServer           = errors.New("server error")
+
+	ErrJobNotCompleted = errors.New("job did not complete") // it failed or was cancelled
+)
+
+// Error is a request the server answered with an error status
+type Error struct {
+	Method     string
+	Path       string
+	StatusCode int
+	Message    string // detail of the server's problem response, empty if it sent none
+}
+
+func (e *Error) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("%s %s failed with status %d", e.Method, e.Path, e.StatusCode)
+	}
+	return fmt.Sprintf("%s %s failed with status %d - %s", e.Method, e.Path, e.StatusCode, e.Message)
+}
+
+// Unwrap returns the sentinel error for the status code, or nil for statuses
+// without one
+func (e *Error) Unwrap() error {
+	switch {
+	case e.StatusCode == http.StatusBadRequest:
+		return ErrBadRequest
+	case e.StatusCode == http.StatusUnauthorized:
+		return ErrUnauthorized
+	case e.StatusCode == http.StatusForbidden:
+		return ErrForbidden
+	case e.StatusCode == http.StatusNotFound:
+		return ErrNotFound
+	case e.StatusCode == http.StatusMethodNotAllowed:
+		return ErrMethodNotAllowed
+	case e.StatusCode == http.StatusConflict:
+		return ErrConflict
+	case e.StatusCode == http.StatusGone:
+		return ErrGone
+	case e.StatusCode == http.StatusTooManyRequests:
+		return ErrTooManyRequests
+	case e.StatusCode >= http.StatusInternalServerError:
+		return ErrServer
+	}
+	return nil
+}
+
+// StatusCode returns the HTTP status of a failed API call, or 0 when err didn't
+// come from a server response
+func StatusCode(err error) int {
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode
+	}
+	return 0
+}
+
+func jobNotCompleted(summary *JobSummary) error {
+	if summary.FailureReason != "" {
+		return fmt.Errorf("%w: job %s %s - %s", ErrJobNotCompleted, summary.ID, summary.State, summary.FailureReason)
+	}
+	return fmt.Errorf("%w: job %s %s", ErrJobNotCompleted, summary.ID, summary.State)
+}
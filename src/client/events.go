@@ -0,0 +1,71 @@
+// Event streams. A job's lifecycle and chunk events, or every event in the cluster,
+// are read from the server's server-sent event stream as they are published.
+
+package client
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"net/http"
+	"strings"
+)
+
+// JobEvents iterates over a job's events as they happen, ending once the job
+// reaches a final state. A failure ends the iteration with a non-nil error.
+func (c *Client) JobEvents(ctx context.Context, jobID string) iter.Seq2[Event, error] {
+	return c.events(ctx, jobPath(jobID, "events"))
+}
+
+// ClusterEvents iterates over every event in the cluster as it happens, until the
+// context is done or the server drops the stream for falling behind. It needs an
+// admin key.
+func (c *Client) ClusterEvents(ctx context.Context) iter.Seq2[Event, error] {
+	return c.events(ctx, "/api/v1/events")
+}
+
+// events reads a server-sent event stream, yielding the decoded data of each event
+func (c *Client) events(ctx context.Context, path string) iter.Seq2[Event, error] {
+	return func(yield func(Event, error) bool) {
+		resp, err := c.send(ctx, http.MethodGet, path, nil)
+		if err != nil {
+			yield(Event{}, err)
+			return
+		}
+		defer resp.Body.Close()
+
+		// Events are separated by blank lines; only their data is needed, since it
+		// repeats the ID and type
+		var data strings.Builder
+		scanner := bufio.NewScanner(resp.Body)
+		for scanner.Scan() {
+			line := scanner.Text()
+			if value, ok := strings.CutPrefix(line, "data:"); ok {
+				if data.Len() > 0 {
+					data.WriteByte('\n')
+				}
+				data.WriteString(strings.TrimPrefix(value, " "))
+				continue
+			}
+			if line != "" || data.Len() == 0 {
+				continue
+			}
+
+			var event Event
+			if err := json.Unmarshal([]byte(data.String()), &event); err != nil {
+				yield(Event{}, fmt.Errorf("failed to decode event - %v", err))
+				return
+			}
+			data.Reset()
+			if !yield(event, nil) {
+				return
+			}
+		}
+
+		if err := scanner.Err(); err != nil && ctx.Err() == nil {
+			yield(Event{}, fmt.Errorf("failed to read events - %v", err))
+		}
+	}
+}
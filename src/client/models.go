@@ -0,0 +1,58 @@
+// Request and response models of the API. They are aliases of the types the server
+// itself encodes and decodes, which live in the small models package so the client
+// doesn't pull in the server, and callers only need to import this package.
+
+package client
+
+import (
+	"distributed-prime-number-generator/src/models"
+)
+
+type (
+	CreateJobRequest   = models.CreateJobRequest
+	UpdateJobRequest   = models.UpdateJobRequest
+	PauseJobRequest    = models.PauseJobRequest
+	JobResponse        = models.JobResponse
+	JobListResponse    = models.JobListResponse
+	WorkerListResponse = models.WorkerListResponse
+	Problem            = models.Problem
+
+	JobState        = models.JobState
+	JobSummary      = models.JobSummary
+	AlgorithmType   = models.AlgorithmType
+	DeliveryState   = models.DeliveryState
+	WebhookDelivery = models.WebhookDelivery
+	Manifest        = models.Manifest
+	ManifestChunk   = models.ManifestChunk
+	ChunkProof      = models.ChunkProof
+	ProofStep       = models.ProofStep
+	WorkerState     = models.WorkerState
+	WorkerSummary   = models.WorkerSummary
+	EventType       = models.EventType
+	Event           = models.Event
+)
+
+const (
+	JobQueued    = models.JobQueued
+	JobRunning   = models.JobRunning
+	JobPaused    = models.JobPaused
+	JobCompleted = models.JobCompleted
+	JobFailed    = models.JobFailed
+	JobCancelled = models.JobCancelled
+)
+
+const (
+	WorkerActive   = models.WorkerActive
+	WorkerDraining = models.WorkerDraining
+	WorkerDrained  = models.WorkerDrained
+	WorkerLost     = models.WorkerLost
+)
+
+const (
+	EventChunkAssigned   = models.EventChunkAssigned
+	EventChunkCompleted  = models.EventChunkCompleted
+	EventWorkerJoined    = models.EventWorkerJoined
+	EventWorkerLost      = models.EventWorkerLost
+	EventJobCompleted    = models.EventJobCompleted
+	EventJobStateChanged = models.EventJobStateChanged
+)
@@ -0,0 +1,62 @@
+// Streaming result download. A large job's result list is decoded one prime at a
+// time as it arrives, so it never has to be held in memory all at once.
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"iter"
+	"net/http"
+)
+
+// Results iterates over the primes found so far for a job, in the order the server
+// lists them. A failure ends the iteration with a non-nil error, for example:
+//
+//	for prime, err := range c.Results(ctx, jobID) {
+//		if err != nil {
+//			return err
+//		}
+//		...
+//	}
+func (c *Client) Results(ctx context.Context, jobID string) iter.Seq2[int, error] {
+	return func(yield func(int, error) bool) {
+		resp, err := c.send(ctx, http.MethodGet, jobPath(jobID, ""), nil)
+		if err != nil {
+			yield(0, err)
+			return
+		}
+		defer resp.Body.Close()
+
+		decoder := json.NewDecoder(resp.Body)
+		token, err := decoder.Token()
+		if err != nil {
+			yield(0, fmt.Errorf("failed to decode results - %v", err))
+			return
+		}
+		if token == nil {
+			// A job without results is listed as null
+			return
+		}
+		if delim, ok := token.(json.Delim); !ok || delim != '[' {
+			yield(0, fmt.Errorf("failed to decode results - expected a list, got %v", token))
+			return
+		}
+
+		for decoder.More() {
+			var prime int
+			if err := decoder.Decode(&prime); err != nil {
+				yield(0, fmt.Errorf("failed to decode results - %v", err))
+				return
+			}
+			if !yield(prime, nil) {
+				return
+			}
+		}
+
+		if _, err := decoder.Token(); err != nil {
+			yield(0, fmt.Errorf("failed to decode results - %v", err))
+		}
+	}
+}
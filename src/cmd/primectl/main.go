@@ -0,0 +1,378 @@
+// Command-line client for the server. Submits jobs, follows and controls them,
+// downloads their results and manages workers through the client package, so
+// nobody has to hand-craft curl commands. Run primectl -h for the subcommands.
+
+package main
+
+import (
+	"bufio"
+	"context"
+	"distributed-prime-number-generator/src/client"
+	"flag"
+	"fmt"
+	"io"
+	"iter"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+type command struct {
+	usage string
+	run   func(ctx context.Context, c *client.Client, args []string) error
+}
+
+var commands = map[string]command{
+	"submit":  {"submit -start N -end N [options]", submit},
+	"status":  {"status JOB", status},
+	"wait":    {"wait [-interval D] [-timeout D] JOB", wait},
+	"list":    {"list [-state S] [-owner O] [-sort F] [-offset N] [-limit N]", list},
+	"cancel":  {"cancel JOB", cancel},
+	"results": {"results [-format text|json|csv] [-o FILE] JOB", results},
+	"workers": {"workers", workers},
+	"drain":   {"drain WORKER", drain},
+}
+
+var commandOrder = []string{"submit", "status", "wait", "list", "cancel", "results", "workers", "drain"}
+
+func main() {
+	serverURL := flag.String("server", envOr("PRIME_SERVER", "http://localhost:8080"), "URL of the coordinator server")
+	apiKey := flag.String("api-key", "", "API key to authenticate with, or $PRIME_API_KEY")
+	caFile := flag.String("ca", "", "CA bundle for verifying the server's certificate")
+	flag.Usage = usage
+	flag.Parse()
+
+	// Read after parsing so the key isn't printed as the flag's default
+	if *apiKey == "" {
+		*apiKey = os.Getenv("PRIME_API_KEY")
+	}
+
+	if flag.NArg() == 0 {
+		usage()
+		os.Exit(2)
+	}
+	cmd, ok := commands[flag.Arg(0)]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "primectl: unknown command %q\n", flag.Arg(0))
+		usage()
+		os.Exit(2)
+	}
+
+	c := client.New(*serverURL, *apiKey)
+	if *caFile != "" {
+		if err := c.UseTLS(*caFile); err != nil {
+			fatal(err)
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := cmd.run(ctx, c, flag.Args()[1:]); err != nil {
+		fatal(err)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: primectl [-server URL] [-api-key KEY] [-ca FILE] COMMAND [options]")
+	fmt.Fprintln(os.Stderr, "\nCommands:")
+	for _, name := range commandOrder {
+		fmt.Fprintf(os.Stderr, "  %s\n", commands[name].usage)
+	}
+	fmt.Fprintln(os.Stderr, "\nGlobal options:")
+	flag.PrintDefaults()
+}
+
+func submit(ctx context.Context, c *client.Client, args []string) error {
+	flags := flag.NewFlagSet("submit", flag.ExitOnError)
+	start := flags.Int("start", 0, "First number of the range")
+	end := flags.Int("end", 0, "Last number of the range")
+	rounds := flags.Int("rounds", 0, "Miller-Rabin rounds (server default when 0)")
+	chunkSize := flags.Int("chunk-size", 0, "Numbers per chunk (server default when 0)")
+	replication := flags.Int("replication", 0, "Workers that must agree on each chunk")
+	priority := flags.Int("priority", 0, "Scheduling priority")
+	callbacks := flags.String("callbacks", "", "Comma-separated URLs to notify when the job finishes")
+	waitFlag := flags.Bool("wait", false, "Wait for the job to finish")
+	flags.Parse(args)
+
+	req := client.CreateJobRequest{
+		Start:       *start,
+		End:         *end,
+		Rounds:      *rounds,
+		ChunkSize:   *chunkSize,
+		Replication: *replication,
+		Priority:    *priority,
+	}
+	if *callbacks != "" {
+		req.Callbacks = strings.Split(*callbacks, ",")
+	}
+
+	jobID, err := c.CreateJob(ctx, req)
+	if err != nil {
+		return err
+	}
+	fmt.Println(jobID)
+
+	if *waitFlag {
+		return waitFor(ctx, c, jobID, client.DefaultPollInterval)
+	}
+	return nil
+}
+
+func status(ctx context.Context, c *client.Client, args []string) error {
+	jobID, err := singleArg("status", "JOB", args)
+	if err != nil {
+		return err
+	}
+
+	summary, err := c.JobStatus(ctx, jobID)
+	if err != nil {
+		return err
+	}
+	printSummary(summary)
+	return nil
+}
+
+func wait(ctx context.Context, c *client.Client, args []string) error {
+	flags := flag.NewFlagSet("wait", flag.ExitOnError)
+	interval := flags.Duration("interval", client.DefaultPollInterval, "Time between status checks")
+	timeout := flags.Duration("timeout", 0, "Give up after this long (no limit when 0)")
+	flags.Parse(args)
+
+	jobID, err := singleArg("wait", "JOB", flags.Args())
+	if err != nil {
+		return err
+	}
+
+	if *timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, *timeout)
+		defer cancel()
+	}
+	return waitFor(ctx, c, jobID, *interval)
+}
+
+// waitFor reports a job's progress on stderr until it finishes, and fails unless it
+// completed
+func waitFor(ctx context.Context, c *client.Client, jobID string, interval time.Duration) error {
+	_, err := c.WaitForJob(ctx, jobID, client.WaitOptions{
+		Interval: interval,
+		OnProgress: func(summary client.JobSummary) {
+			fmt.Fprintf(os.Stderr, "\r%s %-9s %5.1f%%  %d/%d chunks  %d primes ",
+				jobID, summary.State, summary.Progress*100, summary.ChunksCompleted, summary.ChunksCreated, summary.PrimesFound)
+		},
+	})
+	fmt.Fprintln(os.Stderr)
+	return err
+}
+
+func list(ctx context.Context, c *client.Client, args []string) error {
+	flags := flag.NewFlagSet("list", flag.ExitOnError)
+	state := flags.String("state", "", "Only jobs in this state")
+	owner := flags.String("owner", "", "Only jobs created by this key")
+	sortBy := flags.String("sort", "", "Field to sort by, prefixed with - for descending")
+	offset := flags.Int("offset", 0, "Jobs to skip")
+	limit := flags.Int("limit", 0, "Maximum number of jobs to list")
+	flags.Parse(args)
+
+	page, err := c.ListJobs(ctx, client.ListJobsOptions{
+		State:  client.JobState(*state),
+		Owner:  *owner,
+		Sort:   *sortBy,
+		Offset: *offset,
+		Limit:  *limit,
+	})
+	if err != nil {
+		return err
+	}
+
+	table := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(table, "JOB\tSTATE\tRANGE\tPROGRESS\tPRIMES\tOWNER\tCREATED")
+	for _, job := range page.Jobs {
+		fmt.Fprintf(table, "%s\t%s\t%d-%d\t%.1f%%\t%d\t%s\t%s\n", job.ID, job.State, job.Start, job.End,
+			job.Progress*100, job.PrimesFound, job.Owner, job.CreatedAt.Format(time.RFC3339))
+	}
+	table.Flush()
+
+	fmt.Fprintf(os.Stderr, "Showing %d of %d jobs\n", len(page.Jobs), page.Total)
+	return nil
+}
+
+func cancel(ctx context.Context, c *client.Client, args []string) error {
+	jobID, err := singleArg("cancel", "JOB", args)
+	if err != nil {
+		return err
+	}
+
+	summary, err := c.CancelJob(ctx, jobID)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s %s\n", summary.ID, summary.State)
+	return nil
+}
+
+func results(ctx context.Context, c *client.Client, args []string) error {
+	flags := flag.NewFlagSet("results", flag.ExitOnError)
+	format := flags.String("format", "text", "Output format: text (one prime per line), json or csv")
+	output := flags.String("o", "", "File to write to (standard output when empty)")
+	flags.Parse(args)
+
+	jobID, err := singleArg("results", "JOB", flags.Args())
+	if err != nil {
+		return err
+	}
+	if *format != "text" && *format != "json" && *format != "csv" {
+		return fmt.Errorf("unknown format %q", *format)
+	}
+
+	if *output == "" {
+		_, err := writePrimes(os.Stdout, *format, c.Results(ctx, jobID))
+		return err
+	}
+
+	// Write next to the destination and rename on success, so a failed download
+	// leaves any earlier file in place
+	file, err := os.CreateTemp(filepath.Dir(*output), "."+filepath.Base(*output)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to create output file - %v", err)
+	}
+	defer os.Remove(file.Name())
+
+	count, err := writePrimes(file, *format, c.Results(ctx, jobID))
+	if err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Chmod(0o644); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to set output file mode - %v", err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write output file - %v", err)
+	}
+	if err := os.Rename(file.Name(), *output); err != nil {
+		return fmt.Errorf("failed to write output file - %v", err)
+	}
+	fmt.Fprintf(os.Stderr, "Wrote %d primes to %s\n", count, *output)
+	return nil
+}
+
+// writePrimes writes primes as they are downloaded and returns how many it wrote
+func writePrimes(out io.Writer, format string, primes iter.Seq2[int, error]) (int, error) {
+	writer := bufio.NewWriter(out)
+	switch format {
+	case "json":
+		writer.WriteByte('[')
+	case "csv":
+		writer.WriteString("prime\n")
+	}
+
+	count := 0
+	for prime, err := range primes {
+		if err != nil {
+			return count, err
+		}
+		if format == "json" && count > 0 {
+			writer.WriteByte(',')
+		}
+		writer.WriteString(strconv.Itoa(prime))
+		if format != "json" {
+			writer.WriteByte('\n')
+		}
+		count++
+	}
+
+	if format == "json" {
+		writer.WriteString("]\n")
+	}
+	if err := writer.Flush(); err != nil {
+		return count, fmt.Errorf("failed to write results - %v", err)
+	}
+	return count, nil
+}
+
+func workers(ctx context.Context, c *client.Client, args []string) error {
+	pool, err := c.ListWorkers(ctx)
+	if err != nil {
+		return err
+	}
+
+	table := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(table, "WORKER\tSTATE\tVERSION\tLAST SEEN\tACTIVE\tCOMPLETED\tTHROUGHPUT")
+	for _, worker := range pool {
+		throughput := 0.0
+		for _, rate := range worker.Throughput {
+			throughput += rate
+		}
+		fmt.Fprintf(table, "%s\t%s\t%s\t%s ago\t%d\t%d\t%.0f/s\n", worker.ID, worker.State, worker.Version,
+			time.Since(worker.LastHeartbeat).Round(time.Second), len(worker.ActiveChunks), worker.CompletedChunks, throughput)
+	}
+	return table.Flush()
+}
+
+func drain(ctx context.Context, c *client.Client, args []string) error {
+	workerID, err := singleArg("drain", "WORKER", args)
+	if err != nil {
+		return err
+	}
+
+	summary, err := c.DrainWorker(ctx, workerID)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%s %s\n", summary.ID, summary.State)
+	return nil
+}
+
+func printSummary(summary *client.JobSummary) {
+	table := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(table, "Job:\t%s\n", summary.ID)
+	fmt.Fprintf(table, "State:\t%s\n", summary.State)
+	if summary.FailureReason != "" {
+		fmt.Fprintf(table, "Reason:\t%s\n", summary.FailureReason)
+	}
+	fmt.Fprintf(table, "Range:\t%d to %d\n", summary.Start, summary.End)
+	fmt.Fprintf(table, "Progress:\t%.1f%% (%d of %d chunks)\n", summary.Progress*100, summary.ChunksCompleted, summary.ChunksCreated)
+	fmt.Fprintf(table, "Primes:\t%d\n", summary.PrimesFound)
+	fmt.Fprintf(table, "Priority:\t%d\n", summary.Priority)
+	fmt.Fprintf(table, "Replication:\t%d\n", summary.Replication)
+	if summary.Owner != "" {
+		fmt.Fprintf(table, "Owner:\t%s\n", summary.Owner)
+	}
+	fmt.Fprintf(table, "Created:\t%s\n", summary.CreatedAt.Format(time.RFC3339))
+	if summary.StartedAt != nil {
+		fmt.Fprintf(table, "Started:\t%s\n", summary.StartedAt.Format(time.RFC3339))
+	}
+	if summary.FinishedAt != nil {
+		fmt.Fprintf(table, "Finished:\t%s\n", summary.FinishedAt.Format(time.RFC3339))
+	}
+	for _, delivery := range summary.Webhooks {
+		fmt.Fprintf(table, "Webhook:\t%s %s after %d attempts\n", delivery.URL, delivery.State, delivery.Attempts)
+	}
+	table.Flush()
+}
+
+// singleArg returns the only positional argument of a subcommand
+func singleArg(name, what string, args []string) (string, error) {
+	if len(args) != 1 {
+		return "", fmt.Errorf("usage: primectl %s %s", name, what)
+	}
+	return args[0], nil
+}
+
+func envOr(name, fallback string) string {
+	if value := os.Getenv(name); value != "" {
+		return value
+	}
+	return fallback
+}
+
+func fatal(err error) {
+	fmt.Fprintf(os.Stderr, "primectl: %v\n", err)
+	os.Exit(1)
+}
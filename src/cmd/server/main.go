@@ -5,31 +5,93 @@
 package main
 
 import (
+	"context"
 	"distributed-prime-number-generator/src/api"
 	"distributed-prime-number-generator/src/node"
 	"flag"
-	"fmt"
 	"log"
+	"log/slog"
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 )
 
 func main() {
 	// Parse command-line flags
 	port := flag.Int("port", 8080, "API server port")
+	schedulerPolicy := flag.String("scheduler", node.SchedulerFIFO, "Job scheduling policy within a tenant (fifo, priority or fair)")
+	tlsCert := flag.String("tls-cert", "", "Server certificate; enables HTTPS when set")
+	tlsKey := flag.String("tls-key", "", "Private key for the server certificate")
+	clientCA := flag.String("client-ca", "", "CA bundle for verifying worker client certificates")
+	authPath := flag.String("auth", "", "Path to an API key and tenant configuration file (all endpoints are open when empty)")
+	logLevel := flag.String("log-level", "info", "Minimum level of log records (debug, info, warn or error)")
+	logFormat := flag.String("log-format", node.LogFormatText, "Log record format (text or json)")
+	webhookSecret := flag.String("webhook-secret", "", "Key for signing job webhooks, or $PRIME_WEBHOOK_SECRET (jobs can't have callbacks when empty)")
+	webhookAllow := flag.String("webhook-allow", "", "Comma-separated internal networks (CIDR) that webhook callbacks may reach")
+	otlpEndpoint := flag.String("otlp-endpoint", "", "OTLP/HTTP collector (host:port) to send traces to (tracing is disabled when empty)")
 	flag.Parse()
 
-	fmt.Println("=====================================================")
-	fmt.Println("  Distributed Prime Number Generator - Server")
-	fmt.Println("=====================================================")
+	// Read after parsing so the secret isn't printed as the flag's default
+	if *webhookSecret == "" {
+		*webhookSecret = os.Getenv("PRIME_WEBHOOK_SECRET")
+	}
+
+	logger, err := node.NewLogger(os.Stderr, *logLevel, *logFormat)
+	if err != nil {
+		log.Fatalf("Invalid logging flags: %v", err)
+	}
+	slog.SetDefault(logger)
+
+	slog.Info("Distributed Prime Number Generator server starting", "version", node.Version)
+	
+	scheduler, err := node.NewScheduler(*schedulerPolicy)
+	if err != nil {
+		fatal("Invalid scheduler", "error", err)
+	}
 	
 	coordinator := node.NewCoordinator()
-	fmt.Println("Coordinator initialized")
+	coordinator.Scheduler = node.NewTenantScheduler(scheduler)
+	if *webhookSecret != "" {
+		allowed, err := node.ParseNetworks(*webhookAllow)
+		if err != nil {
+			fatal("Invalid -webhook-allow", "error", err)
+		}
+		coordinator.Webhooks = node.NewWebhookNotifier([]byte(*webhookSecret))
+		coordinator.Webhooks.AllowedNetworks = allowed
+	}
+	go coordinator.WatchWorkers(context.Background(), 10*time.Second)
+	slog.Info("Coordinator initialized", "scheduler", scheduler.Name())
+	
+	if *otlpEndpoint != "" {
+		shutdownTracing, err := node.SetupTracing(context.Background(), "prime-server", *otlpEndpoint)
+		if err != nil {
+			fatal("Tracing setup failed", "error", err)
+		}
+		defer shutdownTracing(context.Background())
+		slog.Info("Exporting traces", "endpoint", *otlpEndpoint)
+	}
 	
 	// Create and start the API server
 	server := api.NewServer(coordinator, *port)
-	fmt.Printf("Starting API server on port %d...\n", *port)
+	server.TLSCertFile = *tlsCert
+	server.TLSKeyFile = *tlsKey
+	server.ClientCAFile = *clientCA
+	
+	if *clientCA != "" && *tlsCert == "" {
+		fatal("-client-ca requires -tls-cert and -tls-key")
+	}
+	
+	if *authPath != "" {
+		auth, err := api.LoadAuthConfig(*authPath)
+		if err != nil {
+			fatal("Invalid auth config", "error", err)
+		}
+		auth.Apply(server)
+		slog.Info("Loaded auth config", "path", *authPath, "keys", len(auth.Keys), "tenants", len(auth.Tenants))
+	} else {
+		slog.Warn("No auth config given - all endpoints are open")
+	}
 	
 	// Handle graceful shutdown
 	sigChan := make(chan os.Signal, 1)
@@ -37,13 +99,17 @@ func main() {
 	
 	go func() {
 		if err := server.Start(); err != nil {
-			log.Fatalf("Server error: %v", err)
+			fatal("Server error", "error", err)
 		}
 	}()
 	
-	fmt.Println("Server is running. Press Ctrl+C to shutdown.")
-	
 	// Wait for termination signal
 	<-sigChan
-	fmt.Println("\nShutting down server...")
+	slog.Info("Shutting down server")
+}
+
+// fatal logs an error and exits
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
 }
\ No newline at end of file
@@ -0,0 +1,175 @@
+// Entry point for the independent result checker. Downloads a job's results and
+// manifest from the server and spot-checks them without trusting the workers: each
+// chunk's digest is recomputed from the downloaded primes, and random windows inside
+// every chunk are re-sieved with a segmented sieve to confirm that no composite is
+// listed and no prime is missing.
+
+package main
+
+import (
+	"context"
+	"distributed-prime-number-generator/src/algorithms"
+	"distributed-prime-number-generator/src/client"
+	"distributed-prime-number-generator/src/node"
+	"flag"
+	"fmt"
+	"log"
+	"math/rand"
+	"os"
+	"sort"
+	"time"
+)
+
+func main() {
+	serverURL := flag.String("server", "http://localhost:8080", "URL of the coordinator server")
+	jobID := flag.String("job", "", "ID of the job to verify")
+	samples := flag.Int("samples", 3, "Number of random windows to check in each chunk")
+	window := flag.Int("window", 1000, "Size of each sample window")
+	seed := flag.Int64("seed", 0, "Random seed for choosing windows (0 uses the current time)")
+	apiKey := flag.String("api-key", "", "API key of the tenant that owns the job")
+	caFile := flag.String("ca", "", "CA bundle for verifying the server's certificate")
+	flag.Parse()
+
+	if *jobID == "" {
+		log.Fatal("Missing required -job flag")
+	}
+	if *samples <= 0 || *window <= 0 {
+		log.Fatal("Samples and window must be positive")
+	}
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
+
+	fmt.Println("=====================================================")
+	fmt.Println("  Distributed Prime Number Generator - Verifier")
+	fmt.Println("=====================================================")
+
+	c := client.New(*serverURL, *apiKey)
+	if *caFile != "" {
+		if err := c.UseTLS(*caFile); err != nil {
+			log.Fatalf("Invalid -ca: %v", err)
+		}
+	}
+	ctx := context.Background()
+
+	manifest, err := c.JobManifest(ctx, *jobID)
+	if err != nil {
+		log.Fatalf("Failed to download manifest: %v", err)
+	}
+
+	primes, err := c.JobResults(ctx, *jobID)
+	if err != nil {
+		log.Fatalf("Failed to download results: %v", err)
+	}
+	sort.Ints(primes)
+
+	fmt.Printf("Verifying job %s: %d chunks, %d primes (seed %d)\n",
+		*jobID, len(manifest.Chunks), len(primes), *seed)
+	if !manifest.Complete {
+		fmt.Println("Job is not complete - only chunks with results are checked")
+	}
+
+	rng := rand.New(rand.NewSource(*seed))
+	failed := 0
+	for _, chunk := range manifest.Chunks {
+		if chunk.Digest == "" {
+			fmt.Printf("SKIP %s: no result yet\n", chunk.ChunkID)
+			continue
+		}
+
+		problems, err := verifyChunk(chunk, primesInRange(primes, chunk.Start, chunk.End), rng, *samples, *window)
+		if err != nil {
+			log.Fatalf("Failed to verify chunk %s: %v", chunk.ChunkID, err)
+		}
+
+		if len(problems) == 0 {
+			fmt.Printf("OK   %s (%d to %d)\n", chunk.ChunkID, chunk.Start, chunk.End)
+			continue
+		}
+
+		failed++
+		fmt.Printf("FAIL %s (%d to %d)\n", chunk.ChunkID, chunk.Start, chunk.End)
+		for _, problem := range problems {
+			fmt.Printf("     %s\n", problem)
+		}
+	}
+
+	if failed > 0 {
+		fmt.Printf("%d of %d chunks have discrepancies\n", failed, len(manifest.Chunks))
+		os.Exit(1)
+	}
+
+	fmt.Println("No discrepancies found")
+}
+
+// verifyChunk checks a chunk's listed primes against its manifest digest and
+// re-sieves random sample windows, returning a description of each discrepancy
+func verifyChunk(chunk node.ManifestChunk, listed []int, rng *rand.Rand, samples, window int) ([]string, error) {
+	var problems []string
+
+	if len(listed) != chunk.PrimeCount {
+		problems = append(problems, fmt.Sprintf("manifest reports %d primes but %d were downloaded", chunk.PrimeCount, len(listed)))
+	}
+	if digest := node.ResultDigest(chunk.ChunkID, listed); digest != chunk.Digest {
+		problems = append(problems, fmt.Sprintf("digest mismatch: manifest %s, downloaded %s", chunk.Digest, digest))
+	}
+
+	for _, w := range sampleWindows(chunk.Start, chunk.End, rng, samples, window) {
+		expected, err := algorithms.FindPrimesWithSegmentedSieve(w[0], w[1])
+		if err != nil {
+			return nil, err
+		}
+
+		composites, missing := compare(primesInRange(listed, w[0], w[1]), expected)
+		for _, n := range composites {
+			problems = append(problems, fmt.Sprintf("composite %d listed as prime", n))
+		}
+		for _, n := range missing {
+			problems = append(problems, fmt.Sprintf("prime %d missing", n))
+		}
+	}
+
+	return problems, nil
+}
+
+// sampleWindows picks windows inside [start, end]. Chunks no larger than the
+// requested sample cover are checked in full.
+func sampleWindows(start, end int, rng *rand.Rand, samples, window int) [][2]int {
+	size := end - start + 1
+	if size <= samples*window {
+		return [][2]int{{start, end}}
+	}
+
+	windows := make([][2]int, 0, samples)
+	for i := 0; i < samples; i++ {
+		lo := start + rng.Intn(size-window+1)
+		windows = append(windows, [2]int{lo, lo + window - 1})
+	}
+	return windows
+}
+
+// compare returns the listed numbers that are not prime and the primes not listed
+func compare(listed, expected []int) (composites, missing []int) {
+	i, j := 0, 0
+	for i < len(listed) || j < len(expected) {
+		switch {
+		case j == len(expected) || (i < len(listed) && listed[i] < expected[j]):
+			composites = append(composites, listed[i])
+			i++
+		case i == len(listed) || expected[j] < listed[i]:
+			missing = append(missing, expected[j])
+			j++
+		default:
+			i++
+			j++
+		}
+	}
+	return composites, missing
+}
+
+// primesInRange returns the sub-slice of sorted primes that fall within [start, end]
+func primesInRange(primes []int, start, end int) []int {
+	lo := sort.SearchInts(primes, start)
+	hi := sort.SearchInts(primes, end+1)
+	return primes[lo:hi]
+}
@@ -5,10 +5,15 @@
 package main
 
 import (
+	"context"
 	"distributed-prime-number-generator/src/node"
 	"flag"
-	"fmt"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"log"
+	"log/slog"
+	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
@@ -16,13 +21,51 @@ import (
 
 func main() {
 	serverURL := flag.String("server", "http://localhost:8080", "URL of the coordinator server")
+	spoolDir := flag.String("spool", "spool", "Directory where results are stored until the server acknowledges them")
+	apiKey := flag.String("api-key", "", "API key with the worker role (defaults to $PRIME_WORKER_API_KEY)")
+	caFile := flag.String("ca", "", "CA bundle used to verify the server's certificate")
+	certFile := flag.String("cert", "", "Client certificate identifying this worker to the server")
+	keyFile := flag.String("key", "", "Private key for the client certificate")
+	metricsAddr := flag.String("metrics-addr", "", "Address to serve Prometheus metrics on, e.g. :9101 (disabled when empty)")
+	logLevel := flag.String("log-level", "info", "Minimum level of log records (debug, info, warn or error)")
+	logFormat := flag.String("log-format", node.LogFormatText, "Log record format (text or json)")
+	otlpEndpoint := flag.String("otlp-endpoint", "", "OTLP/HTTP collector (host:port) to send traces to (tracing is disabled when empty)")
 	flag.Parse()
 
-	fmt.Println("=====================================================")
-	fmt.Println("  Distributed Prime Number Generator - Worker")
-	fmt.Println("=====================================================")
+	// Read after parsing so the key isn't printed as the flag's default
+	if *apiKey == "" {
+		*apiKey = os.Getenv("PRIME_WORKER_API_KEY")
+	}
+
+	logger, err := node.NewLogger(os.Stderr, *logLevel, *logFormat)
+	if err != nil {
+		log.Fatalf("Invalid logging flags: %v", err)
+	}
+	slog.SetDefault(logger)
+
+	slog.Info("Distributed Prime Number Generator worker starting", "version", node.Version)
+	
+	worker := node.NewWorker(*serverURL, *spoolDir)
+	worker.APIKey = *apiKey
 	
-	worker := node.NewWorker(*serverURL)
+	if *caFile != "" || *certFile != "" || *keyFile != "" {
+		if err := worker.UseTLS(*caFile, *certFile, *keyFile); err != nil {
+			fatal("TLS setup failed", "error", err)
+		}
+	}
+	
+	if *otlpEndpoint != "" {
+		shutdownTracing, err := node.SetupTracing(context.Background(), "prime-worker", *otlpEndpoint)
+		if err != nil {
+			fatal("Tracing setup failed", "error", err)
+		}
+		defer shutdownTracing(context.Background())
+		slog.Info("Exporting traces", "endpoint", *otlpEndpoint)
+	}
+	
+	if *metricsAddr != "" {
+		go serveMetrics(*metricsAddr, worker)
+	}
 	
 	// Handle graceful shutdown
 	sigChan := make(chan os.Signal, 1)
@@ -31,22 +74,41 @@ func main() {
 	// Start worker in a goroutine
 	errChan := make(chan error, 1)
 	go func() {
-		err := worker.Run()
-		if err != nil {
-			errChan <- err
-		}
+		errChan <- worker.Run()
 	}()
 	
-	fmt.Printf("Worker started, connecting to %s\n", *serverURL)
-	fmt.Println("Press Ctrl+C to shutdown.")
-	
 	select {
 	case err := <-errChan:
 		if err != nil {
-			log.Fatalf("Worker error: %v", err)
+			fatal("Worker error", "error", err)
 		}
-		fmt.Println("Worker completed all work successfully.")
+		slog.Info("Worker drained, shutting down")
 	case <-sigChan:
-		fmt.Println("\nShutting down worker...")
+		slog.Info("Shutting down worker")
 	}
-}
\ No newline at end of file
+}
+
+// serveMetrics exposes the worker's metrics, along with the Go runtime's, for
+// Prometheus to scrape
+func serveMetrics(addr string, worker *node.Worker) {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	registry.MustRegister(worker.Collectors()...)
+	
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(registry, promhttp.HandlerOpts{}))
+	
+	slog.Info("Serving metrics", "addr", addr)
+	if err := http.ListenAndServe(addr, mux); err != nil {
+		fatal("Metrics server error", "error", err)
+	}
+}
+
+// fatal logs an error and exits
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
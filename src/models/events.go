@@ -0,0 +1,35 @@
+// Cluster events, as published by the coordinator's event bus and streamed to
+// clients by the server.
+
+package models
+
+import (
+	"time"
+)
+
+type EventType string
+
+const (
+	EventChunkAssigned   EventType = "chunk-assigned"
+	EventChunkCompleted  EventType = "chunk-completed"
+	EventWorkerJoined    EventType = "worker-joined"
+	EventWorkerLost      EventType = "worker-lost"
+	EventJobCompleted    EventType = "job-completed"
+	EventJobStateChanged EventType = "job-state-changed" // any other lifecycle transition
+)
+
+// Event is a single change in the cluster. Fields that don't apply to an event's
+// type are left empty.
+type Event struct {
+	ID       uint64    `json:"id"` // increases by one with every event published
+	Type     EventType `json:"type"`
+	Time     time.Time `json:"time"`
+	JobID    string    `json:"jobId"`
+	ChunkID  string    `json:"chunkId"`
+	WorkerID string    `json:"workerId"`
+	Start    int       `json:"start"`
+	End      int       `json:"end"`
+	Primes   int       `json:"primes"`
+	State    JobState  `json:"state"`
+	Reason   string    `json:"reason"`
+}
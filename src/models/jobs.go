@@ -0,0 +1,67 @@
+// Job models shared by the server and its clients: a job's lifecycle state, the
+// summary listings and status requests return, and the delivery of its webhooks.
+
+package models
+
+import (
+	"time"
+)
+
+type JobState string
+
+const (
+	JobQueued    JobState = "queued"
+	JobRunning   JobState = "running"
+	JobCompleted JobState = "completed"
+	JobFailed    JobState = "failed"
+	JobCancelled JobState = "cancelled"
+	JobPaused    JobState = "paused"
+)
+
+// Terminal reports whether the state is final
+func (s JobState) Terminal() bool {
+	return s == JobCompleted || s == JobFailed || s == JobCancelled
+}
+
+type AlgorithmType string
+
+// JobSummary is the overview of a job returned by listings
+type JobSummary struct {
+	ID              string                `json:"id"`
+	State           JobState              `json:"state"`
+	FailureReason   string                `json:"failureReason"`
+	Owner           string                `json:"owner"`
+	Tenant          string                `json:"tenant"`
+	Start           int                   `json:"start"`
+	End             int                   `json:"end"`
+	Rounds          int                   `json:"rounds"`
+	Replication     int                   `json:"replication"`
+	Priority        int                   `json:"priority"`
+	AlgorithmMix    map[AlgorithmType]int `json:"algorithmMix"` // numbers in the range handled by each algorithm
+	Progress        float64               `json:"progress"`     // fraction of the range with accepted results
+	ChunksCreated   int                   `json:"chunksCreated"`
+	ChunksCompleted int                   `json:"chunksCompleted"`
+	PrimesFound     int                   `json:"primesFound"`
+	CreatedAt       time.Time             `json:"createdAt"`
+	StartedAt       *time.Time            `json:"startedAt"`
+	FinishedAt      *time.Time            `json:"finishedAt"`
+	Webhooks        []WebhookDelivery     `json:"webhooks"` // delivery of the job's callbacks, once it has finished
+}
+
+type DeliveryState string
+
+const (
+	DeliveryPending   DeliveryState = "pending"
+	DeliveryDelivered DeliveryState = "delivered"
+	DeliveryFailed    DeliveryState = "failed" // gave up after the last attempt or a rejection
+)
+
+// WebhookDelivery tracks sending a job's summary to one of its callback URLs
+type WebhookDelivery struct {
+	URL         string        `json:"url"`
+	State       DeliveryState `json:"state"`
+	Attempts    int           `json:"attempts"`
+	LastError   string        `json:"lastError"`
+	LastAttempt *time.Time    `json:"lastAttempt"`
+	DeliveredAt *time.Time    `json:"deliveredAt"`
+}
@@ -0,0 +1,35 @@
+// Verifiable result manifests. A manifest lists the digest of every chunk of a job
+// in range order together with the Merkle root over them, and a chunk proof is the
+// path of sibling hashes from one chunk's digest to that root.
+
+package models
+
+type ManifestChunk struct {
+	ChunkID    string `json:"chunkId"`
+	Start      int    `json:"start"`
+	End        int    `json:"end"`
+	Digest     string `json:"digest"`
+	PrimeCount int    `json:"primeCount"`
+}
+
+type Manifest struct {
+	JobID    string          `json:"jobId"`
+	Complete bool            `json:"complete"`
+	Root     string          `json:"root"`
+	Chunks   []ManifestChunk `json:"chunks"`
+}
+
+type ChunkProof struct {
+	JobID   string      `json:"jobId"`
+	ChunkID string      `json:"chunkId"`
+	Index   int         `json:"index"`
+	Digest  string      `json:"digest"`
+	Root    string      `json:"root"`
+	Proof   []ProofStep `json:"proof"`
+}
+
+// ProofStep is one sibling hash on the path from a Merkle leaf to the root
+type ProofStep struct {
+	Hash string `json:"hash"`
+	Left bool   `json:"left"` // sibling is on the left of the running hash
+}
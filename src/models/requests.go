@@ -0,0 +1,50 @@
+// Request and response bodies of the REST API that aren't coordinator state, and
+// the problem details error responses carry.
+
+package models
+
+type CreateJobRequest struct {
+	Start       int      `json:"start"`
+	End         int      `json:"end"`
+	Rounds      int      `json:"rounds"`
+	ChunkSize   int      `json:"chunkSize"`
+	Replication int      `json:"replication"`
+	Priority    int      `json:"priority"`
+	Callbacks   []string `json:"callbacks"` // URLs sent a signed summary when the job finishes
+}
+
+type UpdateJobRequest struct {
+	Priority *int `json:"priority"`
+}
+
+type PauseJobRequest struct {
+	Recall bool `json:"recall"` // also take back chunks workers are computing
+}
+
+type JobResponse struct {
+	JobID string `json:"jobId"`
+}
+
+type JobListResponse struct {
+	Jobs   []JobSummary `json:"jobs"`
+	Total  int          `json:"total"`
+	Offset int          `json:"offset"`
+	Limit  int          `json:"limit"`
+}
+
+type RegisterWorkerRequest struct {
+	Version string `json:"version"`
+}
+
+type WorkerListResponse struct {
+	Workers []WorkerSummary `json:"workers"`
+}
+
+// Problem is an RFC 7807 problem details error response
+type Problem struct {
+	Type     string `json:"type"` // about:blank, the status code says what went wrong
+	Title    string `json:"title"`
+	Status   int    `json:"status"`
+	Detail   string `json:"detail,omitempty"`
+	Instance string `json:"instance,omitempty"`
+}
@@ -0,0 +1,32 @@
+// Worker models shared by the server and its clients: a worker's state in the pool
+// and the summary worker listings return.
+
+package models
+
+import (
+	"time"
+)
+
+type WorkerState string
+
+const (
+	WorkerActive   WorkerState = "active"
+	WorkerDraining WorkerState = "draining" // finishing its current chunks
+	WorkerDrained  WorkerState = "drained"  // draining with nothing left in flight
+	WorkerLost     WorkerState = "lost"     // not heard from for the server's stale timeout
+)
+
+// WorkerSummary is the overview of a worker returned by listings
+type WorkerSummary struct {
+	ID              string                    `json:"id"`
+	Version         string                    `json:"version"`
+	State           WorkerState               `json:"state"`
+	RegisteredAt    time.Time                 `json:"registeredAt"`
+	LastHeartbeat   time.Time                 `json:"lastHeartbeat"`
+	ActiveChunks    []string                  `json:"activeChunks"`
+	CompletedChunks int                       `json:"completedChunks"`
+	Agreements      int                       `json:"agreements"`
+	Disagreements   int                       `json:"disagreements"`
+	Unreliable      bool                      `json:"unreliable"`
+	Throughput      map[AlgorithmType]float64 `json:"throughput"` // numbers checked per second
+}
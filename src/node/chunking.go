@@ -0,0 +1,110 @@
+// Adaptive chunk sizing for the coordinator. Rather than cutting every job into
+// fixed-size pieces up front, the next chunk is carved from a job's remaining range
+// when a worker asks for work. Its size is chosen from the throughput measured on
+// earlier chunks so that each chunk takes roughly the same wall-clock time, whatever
+// the algorithm or the speed of the worker.
+
+package node
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+const (
+	// Wall-clock time each chunk should take once throughput has been measured
+	DefaultTargetChunkDuration = 30 * time.Second
+
+	MinChunkSize = 100
+	MaxChunkSize = 10000000
+
+	// Weight given to the newest measurement in the throughput moving average
+	throughputSmoothing = 0.5
+)
+
+// carveChunk creates the next chunk from a job's remaining range, enqueues any
+// extra replicas, and returns it for leasing to the worker. The job must have range
+// left. Callers must hold the mutex.
+func (c *Coordinator) carveChunk(job *Job, worker *WorkerInfo) *WorkChunk {
+	start := job.Cursor
+	algorithm := SOE
+	if start >= TRANSITION_THRESHOLD {
+		algorithm = MRPT
+	}
+
+	end := start + c.chunkSize(worker, job, algorithm) - 1
+	// Keep each chunk to a single algorithm so its runtime is a fair sample
+	if algorithm == SOE && end >= TRANSITION_THRESHOLD {
+		end = TRANSITION_THRESHOLD - 1
+	}
+	if end > job.End {
+		end = job.End
+	}
+	job.Cursor = end + 1
+
+	chunkID := fmt.Sprintf("%s-chunk-%d-%d", job.ID, start, end)
+	chunk := &WorkChunk{
+		ID:          chunkID,
+		JobID:       job.ID,
+		Start:       start,
+		End:         end,
+		Rounds:      job.Rounds,
+		Algorithm:   algorithm,
+		Replication: job.Replication,
+	}
+
+	c.Chunks[chunkID] = chunk
+	c.Replicas[chunkID] = newChunkReplicas(job.Replication)
+	job.ChunkIDs = append(job.ChunkIDs, chunkID)
+	for i := 1; i < job.Replication; i++ {
+		job.Pending = append(job.Pending, chunkID)
+	}
+
+	slog.Debug("Created chunk", "job_id", job.ID, "chunk_id", chunkID, "start", start, "end", end,
+		"algorithm", algorithm)
+
+	return chunk
+}
+
+// chunkSize picks the size of the next chunk for a worker. It prefers the worker's
+// own throughput for the algorithm, falls back to the cluster-wide average, and uses
+// the job's requested chunk size until anything has been measured.
+func (c *Coordinator) chunkSize(worker *WorkerInfo, job *Job, algorithm AlgorithmType) int {
+	rate := worker.Throughput[algorithm]
+	if rate == 0 {
+		rate = c.Throughput[algorithm]
+	}
+	if rate == 0 {
+		return job.ChunkSize
+	}
+
+	size := int(rate * c.TargetChunkDuration.Seconds())
+	if size < MinChunkSize {
+		size = MinChunkSize
+	}
+	if size > MaxChunkSize {
+		size = MaxChunkSize
+	}
+
+	return size
+}
+
+// recordThroughput folds a completed chunk's runtime into the worker and cluster
+// throughput averages. Callers must hold the mutex.
+func (c *Coordinator) recordThroughput(worker *WorkerInfo, chunk *WorkChunk, runtime time.Duration) {
+	if chunk == nil || runtime <= 0 {
+		return
+	}
+
+	rate := float64(chunk.End-chunk.Start+1) / runtime.Seconds()
+	worker.Throughput[chunk.Algorithm] = smoothThroughput(worker.Throughput[chunk.Algorithm], rate)
+	c.Throughput[chunk.Algorithm] = smoothThroughput(c.Throughput[chunk.Algorithm], rate)
+}
+
+func smoothThroughput(current, sample float64) float64 {
+	if current == 0 {
+		return sample
+	}
+	return throughputSmoothing*sample + (1-throughputSmoothing)*current
+}
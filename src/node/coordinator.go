@@ -6,12 +6,21 @@
 package node
 
 import (
+	"context"
+	"crypto/rand"
+	"distributed-prime-number-generator/src/models"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"log/slog"
 	"sync"
 	"time"
 )
 
-type AlgorithmType string
+type AlgorithmType = models.AlgorithmType
 
 const (
     SOE AlgorithmType = "Sieve of Eratosthenes"
@@ -19,34 +28,97 @@ const (
     TRANSITION_THRESHOLD = 100000000
 )
 
+type AssignmentState string
+
+const (
+	AssignmentActive    AssignmentState = "active"
+	AssignmentCompleted AssignmentState = "completed"
+	AssignmentExpired   AssignmentState = "expired"
+)
+
+// Default time a worker has to return a result before its lease is reclaimed
+const DefaultLeaseTimeout = 10 * time.Minute
+
+// Results from expired leases kept for inspection; older ones are dropped
+const MaxStaleResults = 100
+
+var (
+	ErrUnknownAssignment = errors.New("unknown assignment")
+	ErrStaleAssignment   = errors.New("stale assignment")
+	ErrDigestMismatch    = errors.New("result digest mismatch")
+)
+
 type WorkChunk struct {
     ID        string
+	JobID     string
     Start     int
     End       int
 	Algorithm AlgorithmType
     Rounds    int
+	Replication int
+	Token     string
+	TraceContext map[string]string // places the lease in its job's trace
 }
 
 type ChunkResult struct {
-	ChunkID string
-	Primes  []int
-	Runtime time.Duration
+	ChunkID  string
+	WorkerID string
+	Token    string
+	Primes   []int
+	Digest   string
+	Runtime  time.Duration
+	TraceContext map[string]string // copied from the chunk's lease
+
+	accepted map[string]string // worker by token of every result taken for the chunk, set once verified
+}
+
+// Assignment is a single lease of a chunk to a worker, identified by a unique token
+type Assignment struct {
+	Token      string
+	ChunkID    string
+	WorkerID   string
+	AssignedAt time.Time
+	State      AssignmentState
+
+	span       trace.Span // open from lease until the result is accepted or the lease ends
 }
 
 type WorkerInfo struct {
 	ID            string
+	Version       string
+	Owner         string // principal that registered the worker
+	RegisteredAt  time.Time
 	LastHeartbeat time.Time
+	Draining      bool // finishing its current chunks, then leaving the pool
+	Lost          bool // not heard from for WorkerStaleAfter
 	ActiveChunks  []string
 	CompletedJobs int
+	Agreements    int
+	Disagreements int
+	Unreliable    bool
+	Throughput    map[AlgorithmType]float64 // numbers checked per second
 }
 
 type Coordinator struct {
     Workers       map[string]*WorkerInfo
     Chunks        map[string]*WorkChunk
     Results       map[string]*ChunkResult
-    JobChunks     map[string][]string
-    PendingChunks []string
+    Jobs          map[string]*Job
+    ActiveJobs    []string // jobs with chunks left to hand out
+    Scheduler     Scheduler
+    Assignments   map[string]*Assignment
+    StaleResults  []*ChunkResult // latest results of expired leases, oldest first
+    Replicas      map[string]*ChunkReplicas // chunks still waiting for a verified result
+    Throughput    map[AlgorithmType]float64
+    Quotas        map[string]TenantQuota
+    Events        *EventBus
+    Webhooks      *WebhookNotifier // nil when jobs can't have callbacks
+    LeaseTimeout  time.Duration
+    ReplicaWaitTimeout time.Duration
+    TargetChunkDuration time.Duration
     Mutex         sync.Mutex
+
+    metrics       *coordinatorMetrics
 }
 
 func NewCoordinator() *Coordinator {
@@ -54,79 +126,159 @@ func NewCoordinator() *Coordinator {
         Workers:       make(map[string]*WorkerInfo),
         Chunks:        make(map[string]*WorkChunk),
         Results:       make(map[string]*ChunkResult),
-        JobChunks:     make(map[string][]string),
-        PendingChunks: []string{},
+        Jobs:          make(map[string]*Job),
+        ActiveJobs:    []string{},
+        Scheduler:     FIFOScheduler{},
+        Assignments:   make(map[string]*Assignment),
+        Replicas:      make(map[string]*ChunkReplicas),
+        Throughput:    make(map[AlgorithmType]float64),
+        Quotas:        make(map[string]TenantQuota),
+        Events:        NewEventBus(),
+        LeaseTimeout:  DefaultLeaseTimeout,
+        ReplicaWaitTimeout: DefaultReplicaWaitTimeout,
+        TargetChunkDuration: DefaultTargetChunkDuration,
+        metrics:       newCoordinatorMetrics(),
     }
 }
 
-// RegisterWorker adds a new worker to the pool
-func (c *Coordinator) RegisterWorker(workerID string) {
+// RegisterWorker adds a new worker to the pool on behalf of the named principal. A
+// worker registering again under the same ID, as workers identified by certificate
+// do after a restart, keeps its history but is no longer draining.
+func (c *Coordinator) RegisterWorker(workerID, version, owner string) {
 	c.Mutex.Lock()
 	defer c.Mutex.Unlock()
 
+	if worker, exists := c.Workers[workerID]; exists {
+		worker.Version = version
+		worker.Owner = owner
+		worker.RegisteredAt = time.Now()
+		worker.LastHeartbeat = time.Now()
+		worker.Draining = false
+		worker.Lost = false
+		slog.Info("Worker re-registered", "worker_id", workerID, "version", version)
+		c.Events.Publish(Event{Type: EventWorkerJoined, WorkerID: workerID, Reason: "re-registered"})
+		return
+	}
+
 	c.Workers[workerID] = &WorkerInfo{
 		ID:            workerID,
+		Version:       version,
+		Owner:         owner,
+		RegisteredAt:  time.Now(),
 		LastHeartbeat: time.Now(),
 		ActiveChunks:  []string{},
 		CompletedJobs: 0,
+		Throughput:    make(map[AlgorithmType]float64),
 	}
 
-	fmt.Printf("Worker registered: %s\n", workerID)
+	slog.Info("Worker registered", "worker_id", workerID, "version", version)
+	c.Events.Publish(Event{Type: EventWorkerJoined, WorkerID: workerID, Reason: "registered"})
 }
 
-// Divides a range into chunks and prepares them for processing
-func (c *Coordinator) CreateJob(start, end, rounds, chunkSize int) (string, error) {
+// CreateJob registers a range to be searched for primes. Chunks are carved from the
+// range on demand as workers ask for work, sized from the throughput measured so
+// far. Each chunk is computed by spec.Replication distinct workers and only
+// accepted once they agree. The priority is used by the scheduler to choose
+// between jobs, and the job counts towards its tenant's quota.
+func (c *Coordinator) CreateJob(ctx context.Context, spec JobSpec) (jobID string, err error) {
+    _, span := tracer.Start(ctx, "Coordinator.CreateJob")
+    defer func() { endSpan(span, err) }()
+    
+    if spec.Replication <= 0 {
+        spec.Replication = 1
+    }
+    if spec.Replication > MaxReplication {
+        return "", fmt.Errorf("replication factor %d exceeds maximum of %d", spec.Replication, MaxReplication)
+    }
+    if spec.Priority < MinPriority || spec.Priority > MaxPriority {
+        return "", fmt.Errorf("priority %d outside of range %d to %d", spec.Priority, MinPriority, MaxPriority)
+    }
+    if spec.End > MaxRangeEnd {
+        return "", fmt.Errorf("end %d exceeds maximum of %d", spec.End, MaxRangeEnd)
+    }
+    if spec.Rounds < 0 || spec.Rounds > MaxRounds {
+        return "", fmt.Errorf("rounds %d outside of range 0 to %d", spec.Rounds, MaxRounds)
+    }
+    if err := c.validateCallbacks(spec.Callbacks); err != nil {
+        return "", err
+    }
+    
     c.Mutex.Lock()
     defer c.Mutex.Unlock()
     
-    jobID := fmt.Sprintf("job-%d", time.Now().UnixNano())
-    c.JobChunks[jobID] = []string{}
-
-    for chunkStart := start; chunkStart <= end; chunkStart += chunkSize {
-        chunkEnd := chunkStart + chunkSize - 1
-        if chunkEnd > end {
-            chunkEnd = end
-        }
-        
-        algorithm := SOE
-        if chunkStart >= TRANSITION_THRESHOLD || chunkEnd >= TRANSITION_THRESHOLD {
-            algorithm = MRPT
-        }
-        
-        chunkID := fmt.Sprintf("%s-chunk-%d-%d", jobID, chunkStart, chunkEnd)
-        chunk := &WorkChunk{
-            ID:        chunkID,
-            Start:     chunkStart,
-            End:       chunkEnd,
-			Rounds:    rounds,
-            Algorithm: algorithm,
-        }
-        
-        c.Chunks[chunkID] = chunk
-        c.PendingChunks = append(c.PendingChunks, chunkID)
-		c.JobChunks[jobID] = append(c.JobChunks[jobID], chunkID)
-        
-        fmt.Printf("Created chunk: %s (%d to %d) using %s\n", 
-            chunkID, chunkStart, chunkEnd, algorithm)
+    if err := c.checkQuota(spec); err != nil {
+        return "", err
+    }
+    
+    jobID = fmt.Sprintf("job-%d", time.Now().UnixNano())
+    for c.Jobs[jobID] != nil {
+        jobID = fmt.Sprintf("job-%d", time.Now().UnixNano())
+    }
+    job := &Job{
+        JobSpec:     spec,
+        ID:          jobID,
+        State:       JobQueued,
+        Cursor:      spec.Start,
+        ChunkIDs:    []string{},
+        CreatedAt:   time.Now(),
+        spanContext: span.SpanContext(),
     }
+    c.Jobs[jobID] = job
+    c.activateJob(jobID)
+    span.SetAttributes(jobAttributes(job)...)
+    
+    slog.Info("Created job", "job_id", jobID, "start", spec.Start, "end", spec.End,
+        "replication", spec.Replication, "priority", spec.Priority, "tenant", spec.Tenant)
     
     return jobID, nil
 }
 
+// JobOwner returns the name of whoever created a job and the tenant it belongs to
+func (c *Coordinator) JobOwner(jobID string) (owner, tenant string, err error) {
+    c.Mutex.Lock()
+    defer c.Mutex.Unlock()
+    
+    job, exists := c.Jobs[jobID]
+    if !exists {
+        return "", "", fmt.Errorf("job not found: %s", jobID)
+    }
+    
+    return job.Owner, job.Tenant, nil
+}
+
+// SetJobPriority changes the priority of an existing job
+func (c *Coordinator) SetJobPriority(jobID string, priority int) error {
+    if priority < MinPriority || priority > MaxPriority {
+        return fmt.Errorf("priority %d outside of range %d to %d", priority, MinPriority, MaxPriority)
+    }
+    
+    c.Mutex.Lock()
+    defer c.Mutex.Unlock()
+    
+    job, exists := c.Jobs[jobID]
+    if !exists {
+        return fmt.Errorf("job not found: %s", jobID)
+    }
+    
+    job.Priority = priority
+    
+    return nil
+}
+
 // GetJobResults returns only the results for a specific job
 func (c *Coordinator) GetJobResults(jobID string) ([]int, error) {
     c.Mutex.Lock()
     defer c.Mutex.Unlock()
     
     // Check if job exists
-    chunks, exists := c.JobChunks[jobID]
+    job, exists := c.Jobs[jobID]
     if !exists {
         return nil, fmt.Errorf("job not found: %s", jobID)
     }
     
     // Combine results only from this job's chunks
     var jobPrimes []int
-    for _, chunkID := range chunks {
+    for _, chunkID := range job.ChunkIDs {
         if result, ok := c.Results[chunkID]; ok {
             jobPrimes = append(jobPrimes, result.Primes...)
         }
@@ -135,55 +287,225 @@ func (c *Coordinator) GetJobResults(jobID string) ([]int, error) {
     return jobPrimes, nil
 }
 
-// GetNextChunk assigns the next available chunk to a worker
-func (c *Coordinator) GetNextChunk(workerID string) (*WorkChunk, error) {
+// GetNextChunk leases the next available chunk to a worker. The returned chunk
+// carries the assignment token the worker must present when submitting its result.
+func (c *Coordinator) GetNextChunk(ctx context.Context, workerID string) (chunk *WorkChunk, err error) {
+	ctx, span := tracer.Start(ctx, "Coordinator.GetNextChunk", 
+		trace.WithAttributes(attribute.String("worker.id", workerID)))
+	defer func() { endSpan(span, err) }()
+	
 	c.Mutex.Lock()
 	defer c.Mutex.Unlock()
 	
 	worker, exists := c.Workers[workerID]
 	if !exists {
-		return nil, fmt.Errorf("unknown worker (%s)", workerID)
+		return nil, fmt.Errorf("%w (%s)", ErrUnknownWorker, workerID)
+	}
+	
+	c.touchWorker(worker)
+	if worker.Draining {
+		return nil, fmt.Errorf("%w: %s", ErrWorkerDraining, workerID)
 	}
 	
-	worker.LastHeartbeat = time.Now()
+	c.reclaimExpiredLeases()
 	
-	if len(c.PendingChunks) == 0 {
+	now := time.Now()
+	c.failStarvedJobs(now)
+	job := c.pickJob(workerID, now)
+	if job == nil {
 		return nil, nil
 	}
 	
-	chunkID := c.PendingChunks[0]
-	c.PendingChunks = c.PendingChunks[1:]
+	if job.State == JobQueued {
+		c.transitionJob(job, JobRunning, "")
+	}
+	
+	// Work already carved (retries and extra replicas) goes before new chunks
+	index := c.nextPendingIndex(job, workerID)
+	var chunkID string
+	if index >= 0 {
+		chunkID = job.Pending[index]
+	} else {
+		chunkID = c.carveChunk(job, worker).ID
+	}
+	
+	token, err := newAssignmentToken()
+	if err != nil {
+		if index < 0 {
+			job.Pending = append(job.Pending, chunkID)
+		}
+		return nil, err
+	}
+	
+	if index >= 0 {
+		job.Pending = append(job.Pending[:index], job.Pending[index+1:]...)
+	}
+	replicas := c.Replicas[chunkID]
+	replicas.Workers[workerID] = true
+	replicas.Tokens = append(replicas.Tokens, token)
+	replicas.Waiting = now
+	
+	job.Served++
+	job.VirtualTime += 1 / jobWeight(job)
+	job.LastScheduled = now
+	
+	// The lease joins its job's trace rather than the worker's request, which is
+	// linked instead
+	lease := *c.Chunks[chunkID]
+	_, leaseSpan := tracer.Start(trace.ContextWithSpanContext(context.Background(), job.spanContext), "lease chunk",
+		trace.WithLinks(trace.LinkFromContext(ctx)),
+		trace.WithAttributes(chunkAttributes(&lease)...),
+		trace.WithAttributes(attribute.String("worker.id", workerID), attribute.Int("chunk.lease", len(replicas.Tokens))))
+	span.SetAttributes(chunkAttributes(&lease)...)
 	
+	c.Assignments[token] = &Assignment{
+		Token:      token,
+		ChunkID:    chunkID,
+		WorkerID:   workerID,
+		AssignedAt: time.Now(),
+		State:      AssignmentActive,
+		span:       leaseSpan,
+	}
 	worker.ActiveChunks = append(worker.ActiveChunks, chunkID)
 	
-	fmt.Printf("Assigned chunk %s to worker %s\n", chunkID, workerID)
+	slog.Debug("Assigned chunk", "job_id", job.ID, "chunk_id", chunkID, "worker_id", workerID)
+	
+	c.Events.Publish(Event{
+		Type:     EventChunkAssigned,
+		JobID:    job.ID,
+		ChunkID:  chunkID,
+		WorkerID: workerID,
+		Start:    lease.Start,
+		End:      lease.End,
+	})
 	
-	return c.Chunks[chunkID], nil
+	lease.Token = token
+	lease.TraceContext = InjectTraceContext(trace.ContextWithSpan(ctx, leaseSpan))
+	return &lease, nil
 }
 
-// SubmitResult stores the result of a processed chunk
-func (c *Coordinator) SubmitResult(result ChunkResult) error {
+// SubmitResult records the result of a processed chunk. Only results carrying the
+// token of an active lease held by the submitting worker are accepted; resubmitting
+// an already accepted result is acknowledged without being counted twice, and
+// results from expired leases are kept aside in StaleResults. Results whose digest
+// does not match their primes are refused. A chunk's result is published once
+// enough replicas agree on it.
+func (c *Coordinator) SubmitResult(ctx context.Context, result ChunkResult) (err error) {
+	_, span := tracer.Start(ctx, "Coordinator.SubmitResult", trace.WithAttributes(
+		attribute.String("chunk.id", result.ChunkID),
+		attribute.String("worker.id", result.WorkerID),
+	))
+	defer func() { endSpan(span, err) }()
+	
 	c.Mutex.Lock()
 	defer c.Mutex.Unlock()
 	
-	c.Results[result.ChunkID] = &result
-	
-	for workerID, worker := range c.Workers {
-		for i, chunkID := range worker.ActiveChunks {
-			if chunkID == result.ChunkID {
-				worker.ActiveChunks = append(worker.ActiveChunks[:i], worker.ActiveChunks[i+1:]...)
-				worker.CompletedJobs++
-				
-				fmt.Printf("Worker %s completed chunk %s (found %d primes in %v)\n", 
-					workerID, result.ChunkID, len(result.Primes), result.Runtime)
-				break
+	assignment, exists := c.Assignments[result.Token]
+	if !exists {
+		// Leases of verified chunks are released, so a resubmission of any result
+		// taken for the chunk is recognised from the tokens kept with its verdict
+		if verified, ok := c.Results[result.ChunkID]; ok && result.Token != "" &&
+			verified.accepted[result.Token] == result.WorkerID {
+			return nil
+		}
+		
+		// So are the leases of failed and cancelled jobs
+		if chunk, ok := c.Chunks[result.ChunkID]; ok {
+			if job := c.Jobs[chunk.JobID]; job.Terminal() && job.State != JobCompleted {
+				return fmt.Errorf("%w: job %s is %s", ErrStaleAssignment, job.ID, job.State)
 			}
 		}
 	}
+	if !exists || assignment.ChunkID != result.ChunkID || assignment.WorkerID != result.WorkerID {
+		return fmt.Errorf("%w: chunk %s from worker %s", ErrUnknownAssignment, result.ChunkID, result.WorkerID)
+	}
+	
+	switch assignment.State {
+	case AssignmentCompleted:
+		return nil
+	case AssignmentExpired:
+		c.keepStaleResult(&result)
+		slog.Warn("Rejected stale result", "chunk_id", result.ChunkID, "worker_id", result.WorkerID)
+		return fmt.Errorf("%w: lease for chunk %s has expired", ErrStaleAssignment, result.ChunkID)
+	}
+	
+	// Results for failed or cancelled jobs are no longer wanted
+	if job := c.Jobs[c.Chunks[result.ChunkID].JobID]; job.Terminal() && job.State != JobCompleted {
+		c.expireAssignment(assignment)
+		c.releaseAssignment(assignment)
+		return fmt.Errorf("%w: job %s is %s", ErrStaleAssignment, job.ID, job.State)
+	}
+	
+	if !result.VerifyDigest() {
+		return fmt.Errorf("%w: chunk %s from worker %s", ErrDigestMismatch, result.ChunkID, result.WorkerID)
+	}
+	
+	assignment.State = AssignmentCompleted
+	assignment.span.End()
+	c.metrics.chunkDuration.WithLabelValues(string(c.Chunks[result.ChunkID].Algorithm)).Observe(result.Runtime.Seconds())
+	
+	if worker, ok := c.Workers[result.WorkerID]; ok {
+		c.touchWorker(worker)
+		worker.ActiveChunks = removeChunkID(worker.ActiveChunks, result.ChunkID)
+		worker.CompletedJobs++
+		c.recordThroughput(worker, c.Chunks[result.ChunkID], result.Runtime)
+	}
+	
+	slog.Debug("Worker completed chunk", "job_id", c.Chunks[result.ChunkID].JobID, "chunk_id", result.ChunkID,
+		"worker_id", result.WorkerID, "primes", len(result.Primes), "runtime", result.Runtime)
+	
+	c.recordReplica(&result)
+	c.releaseAssignment(assignment)
 	
 	return nil
 }
 
+// reclaimExpiredLeases returns chunks whose lease has run out to the front of the
+// pending queue. Callers must hold the mutex.
+func (c *Coordinator) reclaimExpiredLeases() {
+	now := time.Now()
+	
+	for _, assignment := range c.Assignments {
+		if assignment.State != AssignmentActive || now.Sub(assignment.AssignedAt) < c.LeaseTimeout {
+			continue
+		}
+		
+		c.expireAssignment(assignment)
+		job := c.Jobs[c.Chunks[assignment.ChunkID].JobID]
+		if c.chunkVerified(assignment.ChunkID) || job.Terminal() {
+			c.releaseAssignment(assignment)
+			continue
+		}
+		
+		replicas := c.Replicas[assignment.ChunkID]
+		replicas.Expirations++
+		if replicas.Expirations >= MaxLeaseExpirations {
+			c.failJob(job, fmt.Sprintf("chunk %s was not completed after %d expired leases", 
+				assignment.ChunkID, replicas.Expirations))
+			continue
+		}
+		c.requeueChunk(assignment.ChunkID)
+		
+		slog.Warn("Lease expired, chunk requeued", "job_id", job.ID, "chunk_id", assignment.ChunkID,
+			"worker_id", assignment.WorkerID)
+	}
+}
+
+// expireAssignment ends a lease before its result arrived, freeing the worker's
+// slot and letting another replica of the chunk go to the same worker later.
+// Callers must hold the mutex.
+func (c *Coordinator) expireAssignment(assignment *Assignment) {
+	assignment.State = AssignmentExpired
+	assignment.span.SetStatus(codes.Error, "lease ended without an accepted result")
+	assignment.span.End()
+	if replicas, ok := c.Replicas[assignment.ChunkID]; ok {
+		delete(replicas.Workers, assignment.WorkerID)
+	}
+	if worker, ok := c.Workers[assignment.WorkerID]; ok {
+		worker.ActiveChunks = removeChunkID(worker.ActiveChunks, assignment.ChunkID)
+	}
+}
+
 // GetResults combines all results for completed chunks
 func (c *Coordinator) GetResults() []int {
 	c.Mutex.Lock()
@@ -195,4 +517,112 @@ func (c *Coordinator) GetResults() []int {
 	}
 	
 	return allPrimes
-}
\ No newline at end of file
+}
+
+// pickJob asks the scheduler to choose among the jobs that have work this worker
+// can take. Jobs with nothing left to hand out are dropped from ActiveJobs along the
+// way. Callers must hold the mutex.
+func (c *Coordinator) pickJob(workerID string, now time.Time) *Job {
+	var candidates []*Job
+	active := c.ActiveJobs[:0]
+	for _, jobID := range c.ActiveJobs {
+		job := c.Jobs[jobID]
+		if !job.Schedulable() || (len(job.Pending) == 0 && job.Cursor > job.End) {
+			continue
+		}
+		active = append(active, jobID)
+		
+		if job.Cursor <= job.End || c.nextPendingIndex(job, workerID) >= 0 {
+			candidates = append(candidates, job)
+		}
+	}
+	c.ActiveJobs = active
+	
+	if len(candidates) == 0 {
+		return nil
+	}
+	return c.Scheduler.Pick(candidates, now)
+}
+
+// nextPendingIndex returns the position of the first pending chunk of a job the
+// worker may take, or -1. Replicas of a chunk must go to distinct workers, so any
+// chunk this worker already holds or has computed is skipped. Callers must hold
+// the mutex.
+func (c *Coordinator) nextPendingIndex(job *Job, workerID string) int {
+	for i, chunkID := range job.Pending {
+		if !c.Replicas[chunkID].Workers[workerID] {
+			return i
+		}
+	}
+	return -1
+}
+
+// requeueChunk puts a chunk back at the front of its job's queue, making the job
+// schedulable again if needed. Callers must hold the mutex.
+func (c *Coordinator) requeueChunk(chunkID string) {
+	job := c.Jobs[c.Chunks[chunkID].JobID]
+	job.Pending = append([]string{chunkID}, job.Pending...)
+	c.Replicas[chunkID].Waiting = time.Now()
+	c.activateJob(job.ID)
+}
+
+// activateJob adds a job to the set the scheduler chooses from. A job (re)joining
+// starts no further behind in fair share than the jobs already active, so it can't
+// claim the cluster for itself to catch up. Callers must hold the mutex.
+func (c *Coordinator) activateJob(jobID string) {
+	minVirtualTime := -1.0
+	for _, id := range c.ActiveJobs {
+		if id == jobID {
+			return
+		}
+		if vt := c.Jobs[id].VirtualTime; minVirtualTime < 0 || vt < minVirtualTime {
+			minVirtualTime = vt
+		}
+	}
+	
+	job := c.Jobs[jobID]
+	if job.VirtualTime < minVirtualTime {
+		job.VirtualTime = minVirtualTime
+	}
+	c.ActiveJobs = append(c.ActiveJobs, jobID)
+}
+
+// releaseAssignment forgets a finished lease once its chunk has been verified or
+// its job has ended, so lease bookkeeping only grows with the work still in flight.
+// Callers must hold the mutex.
+func (c *Coordinator) releaseAssignment(assignment *Assignment) {
+	if assignment.State == AssignmentActive {
+		return
+	}
+	job := c.Jobs[c.Chunks[assignment.ChunkID].JobID]
+	if !c.chunkVerified(assignment.ChunkID) && !job.Terminal() {
+		return
+	}
+	delete(c.Assignments, assignment.Token)
+}
+
+// keepStaleResult sets aside a result from an expired lease, dropping the oldest
+// once MaxStaleResults are kept. Callers must hold the mutex.
+func (c *Coordinator) keepStaleResult(result *ChunkResult) {
+	if len(c.StaleResults) >= MaxStaleResults {
+		c.StaleResults = append(c.StaleResults[:0], c.StaleResults[len(c.StaleResults)-MaxStaleResults+1:]...)
+	}
+	c.StaleResults = append(c.StaleResults, result)
+}
+
+func newAssignmentToken() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate assignment token - %v", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+func removeChunkID(chunkIDs []string, chunkID string) []string {
+	for i, id := range chunkIDs {
+		if id == chunkID {
+			return append(chunkIDs[:i], chunkIDs[i+1:]...)
+		}
+	}
+	return chunkIDs
+}
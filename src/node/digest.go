@@ -0,0 +1,162 @@
+// Cryptographic digests for chunk results and Merkle trees over them. Every result
+// carries a SHA-256 digest of its canonical encoding, and each job's verified chunk
+// digests are combined into a Merkle tree so clients can check any chunk, or the
+// whole job, against a single root hash.
+
+package node
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"distributed-prime-number-generator/src/models"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+)
+
+// Domain separation prefixes for Merkle leaves and interior nodes (as in RFC 6962)
+const (
+	merkleLeafPrefix = 0x00
+	merkleNodePrefix = 0x01
+)
+
+// ResultDigest returns the hex SHA-256 digest of a result's canonical encoding: the
+// chunk ID length as a big-endian uint32, the chunk ID, the number of primes as a
+// big-endian uint64 and then each prime in ascending order as a big-endian uint64.
+func ResultDigest(chunkID string, primes []int) string {
+	hash := sha256.New()
+	buf := make([]byte, 8)
+
+	binary.BigEndian.PutUint32(buf[:4], uint32(len(chunkID)))
+	hash.Write(buf[:4])
+	hash.Write([]byte(chunkID))
+
+	binary.BigEndian.PutUint64(buf, uint64(len(primes)))
+	hash.Write(buf)
+	for _, p := range primes {
+		binary.BigEndian.PutUint64(buf, uint64(p))
+		hash.Write(buf)
+	}
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// VerifyDigest reports whether the result's digest matches its contents
+func (r *ChunkResult) VerifyDigest() bool {
+	return r.Digest != "" && r.Digest == ResultDigest(r.ChunkID, r.Primes)
+}
+
+// ProofStep is one sibling hash on the path from a Merkle leaf to the root
+type ProofStep = models.ProofStep
+
+// MerkleRoot returns the hex root hash of a tree over the given hex chunk digests
+func MerkleRoot(digests []string) (string, error) {
+	leaves, err := merkleLeaves(digests)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(merkleHash(leaves)), nil
+}
+
+// MerkleProof returns the inclusion proof for the digest at index
+func MerkleProof(digests []string, index int) ([]ProofStep, error) {
+	if index < 0 || index >= len(digests) {
+		return nil, fmt.Errorf("leaf index %d out of range", index)
+	}
+
+	leaves, err := merkleLeaves(digests)
+	if err != nil {
+		return nil, err
+	}
+
+	return merklePath(leaves, index), nil
+}
+
+// VerifyMerkleProof checks that a chunk digest is included under the given root
+func VerifyMerkleProof(digest string, proof []ProofStep, root string) (bool, error) {
+	leaves, err := merkleLeaves([]string{digest})
+	if err != nil {
+		return false, err
+	}
+
+	current := leaves[0]
+	for _, step := range proof {
+		sibling, err := hex.DecodeString(step.Hash)
+		if err != nil {
+			return false, fmt.Errorf("invalid proof hash %q - %v", step.Hash, err)
+		}
+		if step.Left {
+			current = hashNode(sibling, current)
+		} else {
+			current = hashNode(current, sibling)
+		}
+	}
+
+	expected, err := hex.DecodeString(root)
+	if err != nil {
+		return false, fmt.Errorf("invalid root hash %q - %v", root, err)
+	}
+
+	return bytes.Equal(current, expected), nil
+}
+
+func merkleLeaves(digests []string) ([][]byte, error) {
+	leaves := make([][]byte, len(digests))
+	for i, digest := range digests {
+		raw, err := hex.DecodeString(digest)
+		if err != nil {
+			return nil, fmt.Errorf("invalid digest %q - %v", digest, err)
+		}
+		sum := sha256.Sum256(append([]byte{merkleLeafPrefix}, raw...))
+		leaves[i] = sum[:]
+	}
+	return leaves, nil
+}
+
+// merkleHash computes the root of a tree over already hashed leaves, splitting at
+// the largest power of two smaller than the number of leaves
+func merkleHash(leaves [][]byte) []byte {
+	switch len(leaves) {
+	case 0:
+		sum := sha256.Sum256(nil)
+		return sum[:]
+	case 1:
+		return leaves[0]
+	}
+
+	k := merkleSplit(len(leaves))
+	return hashNode(merkleHash(leaves[:k]), merkleHash(leaves[k:]))
+}
+
+// merklePath returns the sibling hashes from the leaf at index up to the root
+func merklePath(leaves [][]byte, index int) []ProofStep {
+	if len(leaves) <= 1 {
+		return nil
+	}
+
+	k := merkleSplit(len(leaves))
+	if index < k {
+		path := merklePath(leaves[:k], index)
+		return append(path, ProofStep{Hash: hex.EncodeToString(merkleHash(leaves[k:])), Left: false})
+	}
+
+	path := merklePath(leaves[k:], index-k)
+	return append(path, ProofStep{Hash: hex.EncodeToString(merkleHash(leaves[:k])), Left: true})
+}
+
+func merkleSplit(n int) int {
+	k := 1
+	for k*2 < n {
+		k *= 2
+	}
+	return k
+}
+
+func hashNode(left, right []byte) []byte {
+	data := make([]byte, 0, 1+len(left)+len(right))
+	data = append(data, merkleNodePrefix)
+	data = append(data, left...)
+	data = append(data, right...)
+	sum := sha256.Sum256(data)
+	return sum[:]
+}
@@ -0,0 +1,190 @@
+// Event bus for the coordinator. Chunk, worker and job lifecycle events are
+// published as they happen, and subscribers receive either every event or only
+// those of one job. Publishing never blocks the coordinator: a subscriber that
+// falls too far behind is disconnected.
+
+package node
+
+import (
+	"context"
+	"distributed-prime-number-generator/src/models"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+type EventType = models.EventType
+
+const (
+	EventChunkAssigned   = models.EventChunkAssigned
+	EventChunkCompleted  = models.EventChunkCompleted
+	EventWorkerJoined    = models.EventWorkerJoined
+	EventWorkerLost      = models.EventWorkerLost
+	EventJobCompleted    = models.EventJobCompleted
+	EventJobStateChanged = models.EventJobStateChanged // any other lifecycle transition
+)
+
+// Events a subscriber may have queued before it is disconnected
+const EventBufferSize = 256
+
+// Event is a single change in the cluster. Fields that don't apply to an event's
+// type are left empty.
+type Event = models.Event
+
+// EventBus fans events out to subscribers
+type EventBus struct {
+	mutex       sync.Mutex
+	nextID      uint64
+	subscribers map[*Subscription]struct{}
+}
+
+// Subscription receives published events on Events until it is closed, either by
+// the subscriber or by the bus when the subscriber falls behind
+type Subscription struct {
+	Events <-chan Event
+
+	events chan Event
+	jobID  string
+	bus    *EventBus
+}
+
+func NewEventBus() *EventBus {
+	return &EventBus{
+		subscribers: make(map[*Subscription]struct{}),
+	}
+}
+
+// Subscribe starts receiving events. With a job ID only that job's events are
+// delivered, otherwise every event is.
+func (b *EventBus) Subscribe(jobID string) *Subscription {
+	events := make(chan Event, EventBufferSize)
+	subscription := &Subscription{
+		Events: events,
+		events: events,
+		jobID:  jobID,
+		bus:    b,
+	}
+
+	b.mutex.Lock()
+	b.subscribers[subscription] = struct{}{}
+	b.mutex.Unlock()
+
+	return subscription
+}
+
+// Close stops the subscription. It is safe to call more than once.
+func (s *Subscription) Close() {
+	s.bus.mutex.Lock()
+	defer s.bus.mutex.Unlock()
+
+	s.bus.remove(s)
+}
+
+// Publish stamps an event with its ID and time and delivers it to every matching
+// subscriber
+func (b *EventBus) Publish(event Event) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	b.nextID++
+	event.ID = b.nextID
+	event.Time = time.Now()
+
+	for subscription := range b.subscribers {
+		if subscription.jobID != "" && subscription.jobID != event.JobID {
+			continue
+		}
+
+		select {
+		case subscription.events <- event:
+		default:
+			slog.Warn("Disconnecting slow event subscriber", "job_id", subscription.jobID)
+			b.remove(subscription)
+		}
+	}
+}
+
+// remove closes a subscription's channel. Callers must hold the mutex.
+func (b *EventBus) remove(subscription *Subscription) {
+	if _, ok := b.subscribers[subscription]; !ok {
+		return
+	}
+	delete(b.subscribers, subscription)
+	close(subscription.events)
+}
+
+// FinalJobEvent returns the state change that finished a job, for subscribers that
+// arrive after it was published, or nil while the job is still going. The event has
+// no ID, as it isn't published again.
+func (c *Coordinator) FinalJobEvent(jobID string) (*Event, error) {
+	c.Mutex.Lock()
+	defer c.Mutex.Unlock()
+
+	job, exists := c.Jobs[jobID]
+	if !exists {
+		return nil, fmt.Errorf("job not found: %s", jobID)
+	}
+	if !job.Terminal() {
+		return nil, nil
+	}
+
+	event := &Event{Type: EventJobStateChanged, Time: job.FinishedAt, JobID: job.ID, State: job.State, Reason: job.FailureReason}
+	if job.State == JobCompleted {
+		event.Type = EventJobCompleted
+		event.Primes = job.PrimeCount
+	}
+	return event, nil
+}
+
+// WatchWorkers periodically marks workers that haven't been heard from for
+// WorkerStaleAfter as lost, until the context is cancelled
+func (c *Coordinator) WatchWorkers(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.Mutex.Lock()
+			c.detectLostWorkers(time.Now())
+			c.Mutex.Unlock()
+		}
+	}
+}
+
+// detectLostWorkers marks silent workers as lost. Callers must hold the mutex.
+func (c *Coordinator) detectLostWorkers(now time.Time) {
+	for _, worker := range c.Workers {
+		if worker.Lost || now.Sub(worker.LastHeartbeat) <= WorkerStaleAfter {
+			continue
+		}
+
+		worker.Lost = true
+		slog.Warn("Worker lost", "worker_id", worker.ID, "last_heartbeat", worker.LastHeartbeat)
+		c.Events.Publish(Event{
+			Type:     EventWorkerLost,
+			WorkerID: worker.ID,
+			Reason:   "no contact since " + worker.LastHeartbeat.Format(time.RFC3339),
+		})
+	}
+}
+
+// touchWorker records contact from a worker, announcing it again if it had been
+// lost. Callers must hold the mutex.
+func (c *Coordinator) touchWorker(worker *WorkerInfo) {
+	worker.LastHeartbeat = time.Now()
+	if !worker.Lost {
+		return
+	}
+
+	worker.Lost = false
+	slog.Info("Worker reconnected", "worker_id", worker.ID)
+	c.Events.Publish(Event{
+		Type:     EventWorkerJoined,
+		WorkerID: worker.ID,
+		Reason:   "reconnected",
+	})
+}
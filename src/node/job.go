@@ -0,0 +1,285 @@
+// Jobs tracked by the coordinator. A job records the parameters it was created
+// with, how far its range has been carved into chunks, its progress and its
+// lifecycle. Jobs move through an explicit state machine driven by chunk events
+// (the first lease starts a job, the last accepted chunk completes it, chunks that
+// can't be finished fail it) and by operator actions such as pausing and
+// cancellation.
+
+package node
+
+import (
+	"distributed-prime-number-generator/src/models"
+	"errors"
+	"fmt"
+	"go.opentelemetry.io/otel/trace"
+	"log/slog"
+	"time"
+)
+
+type JobState = models.JobState
+
+const (
+	JobQueued    = models.JobQueued
+	JobRunning   = models.JobRunning
+	JobCompleted = models.JobCompleted
+	JobFailed    = models.JobFailed
+	JobCancelled = models.JobCancelled
+	JobPaused    = models.JobPaused
+)
+
+const (
+	// Lease expiries after which a chunk, and with it its job, is failed
+	MaxLeaseExpirations = 3
+
+	// Largest number a job's range may reach, the largest integer JSON clients
+	// can represent exactly
+	MaxRangeEnd = 1<<53 - 1
+
+	// Upper bound on Miller-Rabin rounds; 0 picks the default
+	MaxRounds = 64
+)
+
+var ErrInvalidTransition = errors.New("invalid job state transition")
+
+// Allowed job state transitions; completed, failed and cancelled are final
+var jobTransitions = map[JobState][]JobState{
+	JobQueued:  {JobRunning, JobFailed, JobCancelled, JobPaused},
+	JobRunning: {JobCompleted, JobFailed, JobCancelled, JobPaused},
+	JobPaused:  {JobQueued, JobRunning, JobCancelled},
+}
+
+// JobSpec holds the parameters a job is created with
+type JobSpec struct {
+	Start       int
+	End         int
+	Rounds      int
+	ChunkSize   int
+	Replication int
+	Priority    int
+	Tenant      string
+	Owner       string
+	Callbacks   []string // URLs notified when the job finishes
+}
+
+// Job is a request to find the primes in a range, together with the state the
+// coordinator keeps while carving it into chunks and collecting their results
+type Job struct {
+	JobSpec
+	ID            string
+	State         JobState
+	FailureReason string
+	Cursor        int      // first number not yet assigned to a chunk
+	ChunkIDs      []string // chunks carved so far, in range order
+	Pending       []string // carved chunks waiting for a (further) lease
+	CreatedAt     time.Time
+	StartedAt     time.Time
+	FinishedAt    time.Time
+	LastScheduled time.Time
+	Served        int                // chunks leased so far
+	VirtualTime   float64            // fair share consumed, advanced by 1/weight per lease
+	Verified      int                // chunks with an accepted result
+	VerifiedRange int                // numbers covered by chunks with an accepted result
+	PrimeCount    int                // primes stored for the job
+	Deliveries    []*WebhookDelivery // one per callback, once the job has finished
+
+	spanContext trace.SpanContext // span of the job's creation, parent of its chunk leases
+}
+
+// Terminal reports whether the job has reached a final state
+func (j *Job) Terminal() bool {
+	return j.State.Terminal()
+}
+
+// Schedulable reports whether the job's chunks may be handed out
+func (j *Job) Schedulable() bool {
+	return j.State == JobQueued || j.State == JobRunning
+}
+
+// CancelJob stops a job. Chunks still being computed are discarded when their
+// results arrive, but results already accepted are kept.
+func (c *Coordinator) CancelJob(jobID string) error {
+	c.Mutex.Lock()
+	defer c.Mutex.Unlock()
+
+	job, exists := c.Jobs[jobID]
+	if !exists {
+		return fmt.Errorf("job not found: %s", jobID)
+	}
+
+	return c.transitionJob(job, JobCancelled, "")
+}
+
+// PauseJob stops handing out a job's chunks while keeping its progress. Chunks
+// already leased may finish unless recall is set, in which case their leases are
+// expired and the chunks queued again for when the job resumes.
+func (c *Coordinator) PauseJob(jobID string, recall bool) error {
+	c.Mutex.Lock()
+	defer c.Mutex.Unlock()
+
+	job, exists := c.Jobs[jobID]
+	if !exists {
+		return fmt.Errorf("job not found: %s", jobID)
+	}
+
+	if err := c.transitionJob(job, JobPaused, ""); err != nil {
+		return err
+	}
+
+	if recall {
+		c.recallJobChunks(job)
+	}
+
+	return nil
+}
+
+// ResumeJob puts a paused job back in scheduling
+func (c *Coordinator) ResumeJob(jobID string) error {
+	c.Mutex.Lock()
+	defer c.Mutex.Unlock()
+
+	job, exists := c.Jobs[jobID]
+	if !exists {
+		return fmt.Errorf("job not found: %s", jobID)
+	}
+
+	if job.State != JobPaused {
+		return fmt.Errorf("%w: job %s is %s and can't be resumed", ErrInvalidTransition, job.ID, job.State)
+	}
+
+	to := JobQueued
+	if !job.StartedAt.IsZero() {
+		to = JobRunning
+	}
+	if err := c.transitionJob(job, to, ""); err != nil {
+		return err
+	}
+
+	// Time spent paused doesn't count as waiting for priority aging
+	job.LastScheduled = time.Now()
+	c.activateJob(job.ID)
+
+	// In-flight chunks may have completed the job while it was paused
+	c.checkJobCompleted(job)
+
+	return nil
+}
+
+// recallJobChunks expires every active lease of a job and queues the chunks
+// again. Results for recalled leases are rejected as stale. Callers must hold the
+// mutex.
+func (c *Coordinator) recallJobChunks(job *Job) {
+	recalled := 0
+	for _, assignment := range c.Assignments {
+		if assignment.State != AssignmentActive || c.Chunks[assignment.ChunkID].JobID != job.ID {
+			continue
+		}
+
+		c.expireAssignment(assignment)
+		if c.chunkVerified(assignment.ChunkID) {
+			c.releaseAssignment(assignment)
+			continue
+		}
+
+		c.requeueChunk(assignment.ChunkID)
+		recalled++
+	}
+
+	if recalled > 0 {
+		slog.Info("Recalled in-flight chunks", "job_id", job.ID, "chunks", recalled)
+	}
+}
+
+// transitionJob moves a job to a new state, recording lifecycle timestamps and
+// releasing queued work once the job is final. Callers must hold the mutex.
+func (c *Coordinator) transitionJob(job *Job, to JobState, reason string) error {
+	allowed := false
+	for _, state := range jobTransitions[job.State] {
+		if state == to {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return fmt.Errorf("%w: job %s is %s and can't become %s", ErrInvalidTransition, job.ID, job.State, to)
+	}
+
+	now := time.Now()
+	job.State = to
+	switch to {
+	case JobRunning:
+		if job.StartedAt.IsZero() {
+			job.StartedAt = now
+		}
+	case JobFailed:
+		job.FailureReason = reason
+	}
+
+	if job.Terminal() {
+		job.FinishedAt = now
+		job.Pending = nil
+	}
+	if to == JobFailed || to == JobCancelled {
+		c.dropJobLeases(job)
+	}
+
+	if reason != "" {
+		slog.Info("Job state changed", "job_id", job.ID, "state", to, "reason", reason)
+	} else {
+		slog.Info("Job state changed", "job_id", job.ID, "state", to)
+	}
+
+	event := Event{Type: EventJobStateChanged, JobID: job.ID, State: to, Reason: reason}
+	if to == JobCompleted {
+		event.Type = EventJobCompleted
+		event.Primes = job.PrimeCount
+	}
+	c.Events.Publish(event)
+
+	if job.Terminal() {
+		c.notifyCallbacks(job, event.Type)
+	}
+
+	return nil
+}
+
+// dropJobLeases forgets the leases and replica state of a failed or cancelled job's
+// unverified chunks, ending leases still being computed. Results sent for them
+// later are rejected as stale. Callers must hold the mutex.
+func (c *Coordinator) dropJobLeases(job *Job) {
+	for _, chunkID := range job.ChunkIDs {
+		replicas, ok := c.Replicas[chunkID]
+		if !ok {
+			continue
+		}
+
+		for _, token := range replicas.Tokens {
+			assignment, ok := c.Assignments[token]
+			if !ok {
+				continue
+			}
+			if assignment.State == AssignmentActive {
+				c.expireAssignment(assignment)
+			}
+			delete(c.Assignments, token)
+		}
+		delete(c.Replicas, chunkID)
+	}
+}
+
+// failJob fails a job that can no longer complete. Callers must hold the mutex.
+func (c *Coordinator) failJob(job *Job, reason string) {
+	if job.Terminal() {
+		return
+	}
+	c.transitionJob(job, JobFailed, reason)
+}
+
+// checkJobCompleted completes a job once its whole range has been carved and
+// every chunk has an accepted result. Callers must hold the mutex.
+func (c *Coordinator) checkJobCompleted(job *Job) {
+	if job.State != JobRunning || job.Cursor <= job.End || job.Verified < len(job.ChunkIDs) {
+		return
+	}
+
+	c.transitionJob(job, JobCompleted, fmt.Sprintf("found %d primes", job.PrimeCount))
+}
@@ -0,0 +1,40 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestCancellingJobDropsItsLeases(t *testing.T) {
+	c := NewCoordinator()
+	jobID, err := c.CreateJob(context.Background(), JobSpec{Start: 2, End: 20, ChunkSize: 100, Replication: 2})
+	if err != nil {
+		t.Fatalf("CreateJob failed: %v", err)
+	}
+
+	chunk := leaseChunk(t, c, "a")
+	if err := c.CancelJob(jobID); err != nil {
+		t.Fatalf("CancelJob failed: %v", err)
+	}
+	if len(c.Assignments) != 0 || len(c.Replicas) != 0 {
+		t.Fatalf("%d leases and %d replica sets kept after cancelling, want none", len(c.Assignments), len(c.Replicas))
+	}
+
+	result := resultFor(chunk, "a", []int{2, 3, 5, 7, 11, 13, 17, 19})
+	if err := c.SubmitResult(context.Background(), result); !errors.Is(err, ErrStaleAssignment) {
+		t.Errorf("SubmitResult for a cancelled job returned %v, want %v", err, ErrStaleAssignment)
+	}
+
+	// Nothing is left for lease reclaiming to find either
+	c.LeaseTimeout = 0
+	if _, err := c.GetNextChunk(context.Background(), "a"); err != nil {
+		t.Fatalf("GetNextChunk failed: %v", err)
+	}
+	if len(c.Assignments) != 0 {
+		t.Errorf("%d leases kept, want none", len(c.Assignments))
+	}
+	if workers := c.ListWorkers(); len(workers[0].ActiveChunks) != 0 {
+		t.Errorf("worker still holds chunks %v", workers[0].ActiveChunks)
+	}
+}
@@ -0,0 +1,210 @@
+// Job listing and search for the coordinator. Summarises each job's range,
+// algorithm mix, progress and primes found, and supports filtering, sorting and
+// pagination so clients can discover which jobs exist.
+
+package node
+
+import (
+	"distributed-prime-number-generator/src/models"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultJobPageSize = 50
+	MaxJobPageSize     = 500
+)
+
+// JobSummary is the overview of a job returned by listings
+type JobSummary = models.JobSummary
+
+// JobFilter selects, orders and pages the jobs returned by ListJobs. Zero values
+// match everything.
+type JobFilter struct {
+	State         JobState
+	Owner         string
+	Tenant        string
+	CreatedAfter  time.Time
+	CreatedBefore time.Time
+	RangeStart    int // jobs whose range overlaps [RangeStart, RangeEnd]
+	RangeEnd      int
+	Sort          string // field to sort by, prefixed with - for descending
+	Offset        int
+	Limit         int
+}
+
+var jobSortKeys = map[string]func(a, b *JobSummary) bool{
+	"createdAt": func(a, b *JobSummary) bool { return a.CreatedAt.Before(b.CreatedAt) },
+	"start":     func(a, b *JobSummary) bool { return a.Start < b.Start },
+	"progress":  func(a, b *JobSummary) bool { return a.Progress < b.Progress },
+	"primes":    func(a, b *JobSummary) bool { return a.PrimesFound < b.PrimesFound },
+	"priority":  func(a, b *JobSummary) bool { return a.Priority < b.Priority },
+}
+
+// JobPage is one page of a job listing
+type JobPage struct {
+	Jobs   []JobSummary
+	Total  int // jobs matching the filter across all pages
+	Offset int
+	Limit  int
+}
+
+// ListJobs returns one page of the jobs matching the filter. Jobs are newest first
+// unless another order is requested.
+func (c *Coordinator) ListJobs(filter JobFilter) (*JobPage, error) {
+	if filter.Sort == "" {
+		filter.Sort = "-createdAt"
+	}
+	less, ok := jobSortKeys[strings.TrimPrefix(filter.Sort, "-")]
+	if !ok {
+		return nil, fmt.Errorf("unknown sort field: %s", filter.Sort)
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultJobPageSize
+	}
+	if filter.Limit > MaxJobPageSize {
+		filter.Limit = MaxJobPageSize
+	}
+
+	c.Mutex.Lock()
+	var matches []*JobSummary
+	for _, job := range c.Jobs {
+		summary := c.summarizeJob(job)
+		if filter.matches(&summary) {
+			matches = append(matches, &summary)
+		}
+	}
+	c.Mutex.Unlock()
+
+	descending := strings.HasPrefix(filter.Sort, "-")
+	sort.SliceStable(matches, func(i, j int) bool {
+		a, b := matches[i], matches[j]
+		if less(a, b) == less(b, a) {
+			// Equal keys fall back to the ID so pages are stable
+			return a.ID < b.ID
+		}
+		if descending {
+			return less(b, a)
+		}
+		return less(a, b)
+	})
+
+	total := len(matches)
+	if filter.Offset > total {
+		filter.Offset = total
+	}
+	end := filter.Offset + filter.Limit
+	if end > total {
+		end = total
+	}
+
+	page := make([]JobSummary, 0, end-filter.Offset)
+	for _, summary := range matches[filter.Offset:end] {
+		page = append(page, *summary)
+	}
+
+	return &JobPage{
+		Jobs:   page,
+		Total:  total,
+		Offset: filter.Offset,
+		Limit:  filter.Limit,
+	}, nil
+}
+
+// JobSummary returns the overview of a single job
+func (c *Coordinator) JobSummary(jobID string) (*JobSummary, error) {
+	c.Mutex.Lock()
+	defer c.Mutex.Unlock()
+
+	job, exists := c.Jobs[jobID]
+	if !exists {
+		return nil, fmt.Errorf("job not found: %s", jobID)
+	}
+
+	summary := c.summarizeJob(job)
+	return &summary, nil
+}
+
+// summarizeJob builds a job's summary. Callers must hold the mutex.
+func (c *Coordinator) summarizeJob(job *Job) JobSummary {
+	summary := JobSummary{
+		ID:              job.ID,
+		State:           job.State,
+		FailureReason:   job.FailureReason,
+		Owner:           job.Owner,
+		Tenant:          job.Tenant,
+		Start:           job.Start,
+		End:             job.End,
+		Rounds:          job.Rounds,
+		Replication:     job.Replication,
+		Priority:        job.Priority,
+		AlgorithmMix:    algorithmMix(job.Start, job.End),
+		Progress:        float64(job.VerifiedRange) / float64(job.End-job.Start+1),
+		ChunksCreated:   len(job.ChunkIDs),
+		ChunksCompleted: job.Verified,
+		PrimesFound:     job.PrimeCount,
+		CreatedAt:       job.CreatedAt,
+	}
+
+	if !job.StartedAt.IsZero() {
+		startedAt := job.StartedAt
+		summary.StartedAt = &startedAt
+	}
+	if !job.FinishedAt.IsZero() {
+		finishedAt := job.FinishedAt
+		summary.FinishedAt = &finishedAt
+	}
+	for _, delivery := range job.Deliveries {
+		summary.Webhooks = append(summary.Webhooks, *delivery)
+	}
+
+	return summary
+}
+
+func (f *JobFilter) matches(summary *JobSummary) bool {
+	if f.State != "" && summary.State != f.State {
+		return false
+	}
+	if f.Owner != "" && summary.Owner != f.Owner {
+		return false
+	}
+	if f.Tenant != "" && summary.Tenant != f.Tenant {
+		return false
+	}
+	if !f.CreatedAfter.IsZero() && summary.CreatedAt.Before(f.CreatedAfter) {
+		return false
+	}
+	if !f.CreatedBefore.IsZero() && !summary.CreatedAt.Before(f.CreatedBefore) {
+		return false
+	}
+	if f.RangeEnd > 0 && summary.Start > f.RangeEnd {
+		return false
+	}
+	if f.RangeStart > 0 && summary.End < f.RangeStart {
+		return false
+	}
+	return true
+}
+
+// algorithmMix splits a range at the transition threshold into the numbers each
+// algorithm will handle
+func algorithmMix(start, end int) map[AlgorithmType]int {
+	mix := make(map[AlgorithmType]int)
+	if start < TRANSITION_THRESHOLD {
+		soeEnd := end
+		if soeEnd >= TRANSITION_THRESHOLD {
+			soeEnd = TRANSITION_THRESHOLD - 1
+		}
+		mix[SOE] = soeEnd - start + 1
+	}
+	if end >= TRANSITION_THRESHOLD {
+		mrptStart := start
+		if mrptStart < TRANSITION_THRESHOLD {
+			mrptStart = TRANSITION_THRESHOLD
+		}
+		mix[MRPT] = end - mrptStart + 1
+	}
+	return mix
+}
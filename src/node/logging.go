@@ -0,0 +1,35 @@
+// Logging setup shared by the server and worker binaries. Components log through
+// log/slog with job_id, chunk_id and worker_id attributes, and the binaries choose
+// the minimum level and whether records are written as text or JSON.
+
+package node
+
+import (
+	"fmt"
+	"io"
+	"log/slog"
+)
+
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+// NewLogger creates a logger writing records at or above a level (debug, info,
+// warn or error) in the given format
+func NewLogger(w io.Writer, level, format string) (*slog.Logger, error) {
+	var minLevel slog.Level
+	if err := minLevel.UnmarshalText([]byte(level)); err != nil {
+		return nil, fmt.Errorf("unknown log level %q (expected debug, info, warn or error)", level)
+	}
+	options := &slog.HandlerOptions{Level: minLevel}
+
+	switch format {
+	case LogFormatText:
+		return slog.New(slog.NewTextHandler(w, options)), nil
+	case LogFormatJSON:
+		return slog.New(slog.NewJSONHandler(w, options)), nil
+	default:
+		return nil, fmt.Errorf("unknown log format %q (expected %s or %s)", format, LogFormatText, LogFormatJSON)
+	}
+}
@@ -0,0 +1,119 @@
+// Verifiable result manifests for jobs. A manifest lists the digest of every chunk
+// in range order together with the Merkle root over them, and a chunk proof lets a
+// client check a single downloaded chunk against that root.
+
+package node
+
+import (
+	"distributed-prime-number-generator/src/models"
+	"fmt"
+)
+
+type (
+	ManifestChunk = models.ManifestChunk
+	Manifest      = models.Manifest
+	ChunkProof    = models.ChunkProof
+)
+
+// JobManifest returns the chunk digests of a job. The root hash is only set once
+// every chunk has a verified result.
+func (c *Coordinator) JobManifest(jobID string) (*Manifest, error) {
+	c.Mutex.Lock()
+	defer c.Mutex.Unlock()
+
+	job, exists := c.Jobs[jobID]
+	if !exists {
+		return nil, fmt.Errorf("job not found: %s", jobID)
+	}
+	chunks := job.ChunkIDs
+
+	// Chunks are carved on demand, so the job is only complete once its whole
+	// range has been carved and every chunk has a result
+	manifest := &Manifest{
+		JobID:    jobID,
+		Complete: job.Cursor > job.End,
+		Chunks:   make([]ManifestChunk, 0, len(chunks)),
+	}
+
+	var digests []string
+	for _, chunkID := range chunks {
+		chunk := c.Chunks[chunkID]
+		entry := ManifestChunk{
+			ChunkID: chunkID,
+			Start:   chunk.Start,
+			End:     chunk.End,
+		}
+
+		if result, ok := c.Results[chunkID]; ok {
+			entry.Digest = result.Digest
+			entry.PrimeCount = len(result.Primes)
+			digests = append(digests, result.Digest)
+		} else {
+			manifest.Complete = false
+		}
+
+		manifest.Chunks = append(manifest.Chunks, entry)
+	}
+
+	if manifest.Complete {
+		root, err := MerkleRoot(digests)
+		if err != nil {
+			return nil, err
+		}
+		manifest.Root = root
+	}
+
+	return manifest, nil
+}
+
+// JobChunkProof returns the Merkle inclusion proof of one chunk of a completed job
+func (c *Coordinator) JobChunkProof(jobID, chunkID string) (*ChunkProof, error) {
+	c.Mutex.Lock()
+	defer c.Mutex.Unlock()
+
+	job, exists := c.Jobs[jobID]
+	if !exists {
+		return nil, fmt.Errorf("job not found: %s", jobID)
+	}
+	chunks := job.ChunkIDs
+
+	if job.Cursor <= job.End {
+		return nil, fmt.Errorf("job %s is not complete", jobID)
+	}
+
+	index := -1
+	digests := make([]string, 0, len(chunks))
+	for i, id := range chunks {
+		result, ok := c.Results[id]
+		if !ok {
+			return nil, fmt.Errorf("job %s is not complete", jobID)
+		}
+		if id == chunkID {
+			index = i
+		}
+		digests = append(digests, result.Digest)
+	}
+
+	if index < 0 {
+		return nil, fmt.Errorf("chunk %s not found in job %s", chunkID, jobID)
+	}
+
+	root, err := MerkleRoot(digests)
+	if err != nil {
+		return nil, err
+	}
+
+	proof, err := MerkleProof(digests, index)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ChunkProof{
+		JobID:   jobID,
+		ChunkID: chunkID,
+		Index:   index,
+		Digest:  digests[index],
+		Root:    root,
+		Proof:   proof,
+	}, nil
+}
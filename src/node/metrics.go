@@ -0,0 +1,175 @@
+// Prometheus metrics for the coordinator and workers. The coordinator reports the
+// state of its chunks, jobs and workers when scraped, alongside histograms of chunk
+// runtimes and a count of primes found. Workers report how long they spend
+// computing chunks and fetching work, and how often they have to retry.
+
+package node
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"time"
+)
+
+const metricsNamespace = "primes"
+
+// Workers that haven't been heard from for this long are marked lost, and reported
+// as stale. Workers only call in between chunks, so this is well above the target
+// chunk duration.
+const WorkerStaleAfter = 2 * time.Minute
+
+// Buckets for chunk runtimes, from 10ms to about 10 minutes
+var chunkDurationBuckets = prometheus.ExponentialBuckets(0.01, 2, 17)
+
+type coordinatorMetrics struct {
+	chunkDuration *prometheus.HistogramVec
+	primesFound   prometheus.Counter
+
+	chunks  *prometheus.Desc
+	jobs    *prometheus.Desc
+	workers *prometheus.Desc
+}
+
+func newCoordinatorMetrics() *coordinatorMetrics {
+	return &coordinatorMetrics{
+		chunkDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "chunk_duration_seconds",
+			Help:      "Time workers reported spending on accepted chunk results.",
+			Buckets:   chunkDurationBuckets,
+		}, []string{"algorithm"}),
+		primesFound: prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "found_total",
+			Help:      "Primes found in verified chunks.",
+		}),
+		chunks: prometheus.NewDesc(metricsNamespace+"_chunks",
+			"Chunks by state: pending (carved, waiting for a lease), active (leased) or completed (verified).",
+			[]string{"state"}, nil),
+		jobs: prometheus.NewDesc(metricsNamespace+"_jobs",
+			"Jobs by lifecycle state.",
+			[]string{"state"}, nil),
+		workers: prometheus.NewDesc(metricsNamespace+"_workers",
+			"Registered workers by liveness: alive, stale (marked lost for lack of contact) or draining.",
+			[]string{"state"}, nil),
+	}
+}
+
+// Collectors returns the coordinator's metrics for registering with a registry
+func (c *Coordinator) Collectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		c.metrics.chunkDuration,
+		c.metrics.primesFound,
+		coordinatorCollector{c},
+	}
+}
+
+// coordinatorCollector reports gauges computed from the coordinator's state at
+// scrape time
+type coordinatorCollector struct {
+	c *Coordinator
+}
+
+func (cc coordinatorCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cc.c.metrics.chunks
+	ch <- cc.c.metrics.jobs
+	ch <- cc.c.metrics.workers
+}
+
+func (cc coordinatorCollector) Collect(ch chan<- prometheus.Metric) {
+	c := cc.c
+	c.Mutex.Lock()
+	defer c.Mutex.Unlock()
+
+	pending := 0
+	jobs := map[JobState]int{
+		JobQueued:    0,
+		JobRunning:   0,
+		JobPaused:    0,
+		JobCompleted: 0,
+		JobFailed:    0,
+		JobCancelled: 0,
+	}
+	for _, job := range c.Jobs {
+		pending += len(job.Pending)
+		jobs[job.State]++
+	}
+
+	active := 0
+	for _, assignment := range c.Assignments {
+		if assignment.State == AssignmentActive {
+			active++
+		}
+	}
+
+	// Stale workers are the ones the coordinator has marked lost, so the gauge agrees
+	// with the worker list and its events
+	workers := map[string]int{"alive": 0, "stale": 0, "draining": 0}
+	for _, worker := range c.Workers {
+		switch {
+		case worker.Draining:
+			workers["draining"]++
+		case worker.Lost:
+			workers["stale"]++
+		default:
+			workers["alive"]++
+		}
+	}
+
+	metrics := c.metrics
+	ch <- prometheus.MustNewConstMetric(metrics.chunks, prometheus.GaugeValue, float64(pending), "pending")
+	ch <- prometheus.MustNewConstMetric(metrics.chunks, prometheus.GaugeValue, float64(active), "active")
+	ch <- prometheus.MustNewConstMetric(metrics.chunks, prometheus.GaugeValue, float64(len(c.Results)), "completed")
+	for state, count := range jobs {
+		ch <- prometheus.MustNewConstMetric(metrics.jobs, prometheus.GaugeValue, float64(count), string(state))
+	}
+	for state, count := range workers {
+		ch <- prometheus.MustNewConstMetric(metrics.workers, prometheus.GaugeValue, float64(count), state)
+	}
+}
+
+type workerMetrics struct {
+	chunkDuration *prometheus.HistogramVec
+	fetchDuration prometheus.Histogram
+	retries       *prometheus.CounterVec
+}
+
+func newWorkerMetrics() *workerMetrics {
+	metrics := &workerMetrics{
+		chunkDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Subsystem: "worker",
+			Name:      "chunk_duration_seconds",
+			Help:      "Time spent computing chunks.",
+			Buckets:   chunkDurationBuckets,
+		}, []string{"algorithm"}),
+		fetchDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Subsystem: "worker",
+			Name:      "fetch_duration_seconds",
+			Help:      "Latency of requests to the coordinator for the next chunk.",
+			Buckets:   prometheus.DefBuckets,
+		}),
+		retries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Subsystem: "worker",
+			Name:      "retries_total",
+			Help:      "Operations retried after an error, by operation: fetch, process or submit.",
+		}, []string{"operation"}),
+	}
+
+	// Report every operation from the start, even before its first retry
+	for _, operation := range []string{"fetch", "process", "submit"} {
+		metrics.retries.WithLabelValues(operation)
+	}
+
+	return metrics
+}
+
+// Collectors returns the worker's metrics for registering with a registry
+func (w *Worker) Collectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		w.metrics.chunkDuration,
+		w.metrics.fetchDuration,
+		w.metrics.retries,
+	}
+}
@@ -0,0 +1,218 @@
+// Redundant computation support for the coordinator. Chunks can be computed by
+// several independent workers whose prime lists are compared before the chunk is
+// accepted. Disagreements trigger an extra tie-breaking replica, and workers that
+// repeatedly disagree with the majority are flagged as unreliable.
+
+package node
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+const (
+	// Upper bound on the number of workers a single chunk can be replicated to
+	MaxReplication = 5
+
+	// Disagreements with the majority after which a worker is flagged unreliable
+	UnreliableThreshold = 3
+
+	// Default time a replica may wait with no live worker left that could take it
+	// before its job is failed, giving new workers a chance to join
+	DefaultReplicaWaitTimeout = 10 * time.Minute
+)
+
+// ChunkReplicas tracks the independent computations of a single chunk until it is
+// verified. From then on only its accepted result is kept, in Coordinator.Results.
+type ChunkReplicas struct {
+	Quorum      int                     // agreeing results needed to verify the chunk
+	Target      int                     // replicas scheduled so far, including tie-breakers
+	Workers     map[string]bool         // workers holding or having returned a replica
+	Digests     map[string]string       // digest of each submitted result by worker
+	Candidates  map[string]*ChunkResult // one submitted result for each distinct digest
+	Tokens      []string                // assignment tokens issued for the chunk
+	Expirations int                     // leases of the chunk that ran out
+	Waiting     time.Time               // when a replica was last leased or queued
+}
+
+// MaxReplicaAttempts is the most replicas, tie-breakers included, a chunk is
+// computed by before its job is failed
+func MaxReplicaAttempts(replication int) int {
+	return 2*replication + 1
+}
+
+// chunkVerified reports whether a chunk has an accepted result. Callers must hold
+// the mutex.
+func (c *Coordinator) chunkVerified(chunkID string) bool {
+	_, verified := c.Results[chunkID]
+	return verified
+}
+
+func newChunkReplicas(replication int) *ChunkReplicas {
+	return &ChunkReplicas{
+		Quorum:     replication/2 + 1,
+		Target:     replication,
+		Workers:    make(map[string]bool),
+		Digests:    make(map[string]string),
+		Candidates: make(map[string]*ChunkResult),
+		Waiting:    time.Now(),
+	}
+}
+
+// recordReplica compares a newly accepted result against the other replicas of its
+// chunk. Callers must hold the mutex.
+func (c *Coordinator) recordReplica(result *ChunkResult) {
+	digest := result.Digest
+
+	// Late replicas of a verified chunk only count towards worker reliability
+	if verified, ok := c.Results[result.ChunkID]; ok {
+		verified.accepted[result.Token] = result.WorkerID
+		c.scoreWorker(result.WorkerID, digest == verified.Digest)
+		return
+	}
+
+	replicas := c.Replicas[result.ChunkID]
+	replicas.Digests[result.WorkerID] = digest
+	if _, exists := replicas.Candidates[digest]; !exists {
+		replicas.Candidates[digest] = result
+	}
+
+	votes := make(map[string]int)
+	for _, d := range replicas.Digests {
+		votes[d]++
+	}
+
+	for d, count := range votes {
+		if count < replicas.Quorum {
+			continue
+		}
+
+		verified := replicas.Candidates[d]
+		verified.accepted = make(map[string]string)
+		for _, token := range replicas.Tokens {
+			if assignment, ok := c.Assignments[token]; ok && assignment.State == AssignmentCompleted {
+				verified.accepted[token] = assignment.WorkerID
+			}
+		}
+		c.Results[result.ChunkID] = verified
+
+		chunk := c.Chunks[result.ChunkID]
+		job := c.Jobs[chunk.JobID]
+		job.Verified++
+		job.VerifiedRange += chunk.End - chunk.Start + 1
+		job.PrimeCount += len(verified.Primes)
+		c.metrics.primesFound.Add(float64(len(verified.Primes)))
+		c.Events.Publish(Event{
+			Type:     EventChunkCompleted,
+			JobID:    job.ID,
+			ChunkID:  result.ChunkID,
+			WorkerID: result.WorkerID,
+			Start:    chunk.Start,
+			End:      chunk.End,
+			Primes:   len(verified.Primes),
+		})
+
+		for workerID, workerDigest := range replicas.Digests {
+			c.scoreWorker(workerID, workerDigest == d)
+		}
+
+		// Replicas still waiting in the queue are no longer needed
+		pending := job.Pending[:0]
+		for _, chunkID := range job.Pending {
+			if chunkID != result.ChunkID {
+				pending = append(pending, chunkID)
+			}
+		}
+		job.Pending = pending
+
+		// Only the verdict is needed from now on, so release the per-replica state
+		submitted := len(replicas.Digests)
+		delete(c.Replicas, result.ChunkID)
+		for _, token := range replicas.Tokens {
+			if assignment, ok := c.Assignments[token]; ok {
+				c.releaseAssignment(assignment)
+			}
+		}
+
+		if replicas.Target > 1 {
+			slog.Debug("Chunk verified", "job_id", job.ID, "chunk_id", result.ChunkID, "agreeing", count,
+				"replicas", submitted)
+		}
+
+		c.checkJobCompleted(job)
+		return
+	}
+
+	// Every scheduled replica has reported without reaching a quorum
+	if len(replicas.Digests) >= replicas.Target {
+		job := c.Jobs[c.Chunks[result.ChunkID].JobID]
+		if replicas.Target >= MaxReplicaAttempts(job.Replication) {
+			c.failJob(job, fmt.Sprintf("replicas of chunk %s never reached agreement", result.ChunkID))
+			return
+		}
+
+		replicas.Target++
+		c.requeueChunk(result.ChunkID)
+
+		slog.Warn("Replicas disagree, scheduled tie-breaker", "job_id", job.ID, "chunk_id", result.ChunkID,
+			"replicas", replicas.Target)
+	}
+}
+
+// failStarvedJobs fails jobs with a replica that has waited longer than
+// ReplicaWaitTimeout while every live worker already holds or has returned one of
+// its chunk's replicas, as happens when the replication factor exceeds the number
+// of workers. Callers must hold the mutex.
+func (c *Coordinator) failStarvedJobs(now time.Time) {
+	for _, jobID := range c.ActiveJobs {
+		job := c.Jobs[jobID]
+		if !job.Schedulable() {
+			continue
+		}
+
+		for _, chunkID := range job.Pending {
+			replicas := c.Replicas[chunkID]
+			if now.Sub(replicas.Waiting) < c.ReplicaWaitTimeout || c.canTakeReplica(replicas) {
+				continue
+			}
+
+			slog.Warn("No worker left for replica", "job_id", job.ID, "chunk_id", chunkID,
+				"replicas", replicas.Target, "waiting", now.Sub(replicas.Waiting))
+			c.failJob(job, fmt.Sprintf("chunk %s needs %d replicas from distinct workers, but no live worker is left to take another",
+				chunkID, replicas.Target))
+			break
+		}
+	}
+}
+
+// canTakeReplica reports whether any live worker is free to compute another
+// replica of a chunk. Callers must hold the mutex.
+func (c *Coordinator) canTakeReplica(replicas *ChunkReplicas) bool {
+	for workerID, worker := range c.Workers {
+		if !worker.Lost && !worker.Draining && !replicas.Workers[workerID] {
+			return true
+		}
+	}
+	return false
+}
+
+// scoreWorker records whether a worker agreed with the verified result of a chunk.
+// Callers must hold the mutex.
+func (c *Coordinator) scoreWorker(workerID string, agreed bool) {
+	worker, exists := c.Workers[workerID]
+	if !exists {
+		return
+	}
+
+	if agreed {
+		worker.Agreements++
+		return
+	}
+
+	worker.Disagreements++
+	if !worker.Unreliable && worker.Disagreements >= UnreliableThreshold {
+		worker.Unreliable = true
+		slog.Warn("Worker flagged as unreliable", "worker_id", workerID, "disagreements", worker.Disagreements)
+	}
+}
@@ -0,0 +1,150 @@
+package node
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// leaseChunk registers a worker if needed and leases it the next chunk
+func leaseChunk(t *testing.T, c *Coordinator, workerID string) *WorkChunk {
+	t.Helper()
+
+	if _, registered := c.WorkerOwner(workerID); !registered {
+		c.RegisterWorker(workerID, "test", workerID)
+	}
+	chunk, err := c.GetNextChunk(context.Background(), workerID)
+	if err != nil {
+		t.Fatalf("GetNextChunk(%s) failed: %v", workerID, err)
+	}
+	if chunk == nil {
+		t.Fatalf("GetNextChunk(%s) returned no chunk", workerID)
+	}
+	return chunk
+}
+
+// resultFor builds a correctly digested result for a leased chunk
+func resultFor(chunk *WorkChunk, workerID string, primes []int) ChunkResult {
+	return ChunkResult{
+		ChunkID:  chunk.ID,
+		WorkerID: workerID,
+		Token:    chunk.Token,
+		Primes:   primes,
+		Digest:   ResultDigest(chunk.ID, primes),
+	}
+}
+
+func TestResubmissionsOfEveryTakenReplicaAreAcknowledged(t *testing.T) {
+	c := NewCoordinator()
+	jobID, err := c.CreateJob(context.Background(), JobSpec{Start: 2, End: 20, ChunkSize: 100, Replication: 2})
+	if err != nil {
+		t.Fatalf("CreateJob failed: %v", err)
+	}
+
+	correct := []int{2, 3, 5, 7, 11, 13, 17, 19}
+	wrong := []int{2, 3, 5, 7, 11, 13, 17}
+
+	a := leaseChunk(t, c, "a")
+	b := leaseChunk(t, c, "b")
+	results := []ChunkResult{resultFor(a, "a", correct), resultFor(b, "b", wrong)}
+	for _, result := range results {
+		if err := c.SubmitResult(context.Background(), result); err != nil {
+			t.Fatalf("SubmitResult from %s failed: %v", result.WorkerID, err)
+		}
+	}
+
+	// The disagreement schedules a tie-breaker, whose result settles the chunk
+	tieBreaker := leaseChunk(t, c, "c")
+	results = append(results, resultFor(tieBreaker, "c", correct))
+	if err := c.SubmitResult(context.Background(), results[2]); err != nil {
+		t.Fatalf("SubmitResult from c failed: %v", err)
+	}
+
+	summary, err := c.JobSummary(jobID)
+	if err != nil {
+		t.Fatalf("JobSummary failed: %v", err)
+	}
+	if summary.State != JobCompleted {
+		t.Fatalf("job is %s, want %s", summary.State, JobCompleted)
+	}
+	if len(c.Assignments) != 0 || len(c.Replicas) != 0 {
+		t.Errorf("%d leases and %d replica sets kept after verification, want none", len(c.Assignments), len(c.Replicas))
+	}
+
+	for _, result := range results {
+		if err := c.SubmitResult(context.Background(), result); err != nil {
+			t.Errorf("resubmission from %s failed: %v", result.WorkerID, err)
+		}
+	}
+
+	forged := results[2]
+	forged.WorkerID = "a"
+	if err := c.SubmitResult(context.Background(), forged); !errors.Is(err, ErrUnknownAssignment) {
+		t.Errorf("resubmission under another worker's token returned %v, want %v", err, ErrUnknownAssignment)
+	}
+}
+
+func TestJobsFailWhenTooFewWorkersForReplication(t *testing.T) {
+	c := NewCoordinator()
+	c.ReplicaWaitTimeout = time.Hour
+	jobID, err := c.CreateJob(context.Background(), JobSpec{Start: 2, End: 20, ChunkSize: 100, Replication: 2})
+	if err != nil {
+		t.Fatalf("CreateJob failed: %v", err)
+	}
+
+	// The only worker can't take the chunk's second replica, so the job has nothing
+	// left to offer it
+	chunk := leaseChunk(t, c, "a")
+	if err := c.SubmitResult(context.Background(), resultFor(chunk, "a", []int{2, 3, 5, 7, 11, 13, 17, 19})); err != nil {
+		t.Fatalf("SubmitResult failed: %v", err)
+	}
+	if next, err := c.GetNextChunk(context.Background(), "a"); err != nil || next != nil {
+		t.Fatalf("GetNextChunk returned %+v, %v, want nothing", next, err)
+	}
+	if summary, err := c.JobSummary(jobID); err != nil || summary.State != JobRunning {
+		t.Fatalf("JobSummary returned %+v, %v, want the job still running within the timeout", summary, err)
+	}
+
+	// Once the replica has waited too long without another worker joining, the job
+	// fails instead of running forever
+	c.Replicas[chunk.ID].Waiting = time.Now().Add(-2 * time.Hour)
+	if _, err := c.GetNextChunk(context.Background(), "a"); err != nil {
+		t.Fatalf("GetNextChunk failed: %v", err)
+	}
+	summary, err := c.JobSummary(jobID)
+	if err != nil {
+		t.Fatalf("JobSummary failed: %v", err)
+	}
+	if summary.State != JobFailed || summary.FailureReason == "" {
+		t.Errorf("job is %s with reason %q, want it failed with a reason", summary.State, summary.FailureReason)
+	}
+
+	// A second worker joining in time takes the replica instead
+	jobID, err = c.CreateJob(context.Background(), JobSpec{Start: 2, End: 20, ChunkSize: 100, Replication: 2})
+	if err != nil {
+		t.Fatalf("CreateJob failed: %v", err)
+	}
+	chunk = leaseChunk(t, c, "a")
+	c.Replicas[chunk.ID].Waiting = time.Now().Add(-2 * time.Hour)
+	if replica := leaseChunk(t, c, "b"); replica.ID != chunk.ID {
+		t.Errorf("second worker got chunk %s, want the replica of %s", replica.ID, chunk.ID)
+	}
+	if summary, err := c.JobSummary(jobID); err != nil || summary.State != JobRunning {
+		t.Errorf("JobSummary returned %+v, %v, want the job running", summary, err)
+	}
+}
+
+func TestStaleResultsAreCapped(t *testing.T) {
+	c := NewCoordinator()
+	for i := 0; i < MaxStaleResults+10; i++ {
+		c.keepStaleResult(&ChunkResult{ChunkID: "chunk", Primes: []int{i}})
+	}
+
+	if len(c.StaleResults) != MaxStaleResults {
+		t.Fatalf("%d stale results kept, want %d", len(c.StaleResults), MaxStaleResults)
+	}
+	if newest := c.StaleResults[len(c.StaleResults)-1].Primes[0]; newest != MaxStaleResults+9 {
+		t.Errorf("newest stale result is %d, want %d", newest, MaxStaleResults+9)
+	}
+}
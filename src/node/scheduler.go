@@ -0,0 +1,173 @@
+// Scheduling policies that decide which job receives the next chunk when a worker
+// asks for work. The coordinator offers the scheduler every job that has work the
+// worker can take, and the policy picks one: oldest first, highest priority with
+// aging, or weighted fair sharing between concurrent jobs.
+
+package node
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	SchedulerFIFO     = "fifo"
+	SchedulerPriority = "priority"
+	SchedulerFair     = "fair"
+
+	MinPriority = 0
+	MaxPriority = 10
+
+	// Waiting time that raises a job's effective priority by one level
+	DefaultAgingInterval = 30 * time.Second
+)
+
+type Scheduler interface {
+	// Name returns the policy name used to select the scheduler
+	Name() string
+	// Pick returns the job that should receive the next chunk. candidates is never
+	// empty and is ordered by when each job became schedulable.
+	Pick(candidates []*Job, now time.Time) *Job
+}
+
+// NewScheduler returns the scheduler for a policy name
+func NewScheduler(policy string) (Scheduler, error) {
+	switch policy {
+	case SchedulerFIFO:
+		return FIFOScheduler{}, nil
+	case SchedulerPriority:
+		return PriorityScheduler{AgingInterval: DefaultAgingInterval}, nil
+	case SchedulerFair:
+		return FairScheduler{}, nil
+	default:
+		return nil, fmt.Errorf("unknown scheduler policy: %s", policy)
+	}
+}
+
+// FIFOScheduler serves jobs strictly in creation order
+type FIFOScheduler struct{}
+
+func (FIFOScheduler) Name() string {
+	return SchedulerFIFO
+}
+
+func (FIFOScheduler) Pick(candidates []*Job, now time.Time) *Job {
+	best := candidates[0]
+	for _, job := range candidates[1:] {
+		if job.CreatedAt.Before(best.CreatedAt) {
+			best = job
+		}
+	}
+	return best
+}
+
+// PriorityScheduler serves the job with the highest priority. A job's effective
+// priority grows by one level for every AgingInterval it waits without being
+// served, so low priority jobs are never starved indefinitely.
+type PriorityScheduler struct {
+	AgingInterval time.Duration
+}
+
+func (PriorityScheduler) Name() string {
+	return SchedulerPriority
+}
+
+func (s PriorityScheduler) Pick(candidates []*Job, now time.Time) *Job {
+	best := candidates[0]
+	bestScore := s.effectivePriority(best, now)
+	for _, job := range candidates[1:] {
+		score := s.effectivePriority(job, now)
+		if score > bestScore || (score == bestScore && job.CreatedAt.Before(best.CreatedAt)) {
+			best, bestScore = job, score
+		}
+	}
+	return best
+}
+
+func (s PriorityScheduler) effectivePriority(job *Job, now time.Time) float64 {
+	waitingSince := job.CreatedAt
+	if job.LastScheduled.After(waitingSince) {
+		waitingSince = job.LastScheduled
+	}
+
+	priority := float64(job.Priority)
+	if s.AgingInterval > 0 {
+		priority += float64(now.Sub(waitingSince)) / float64(s.AgingInterval)
+	}
+	return priority
+}
+
+// FairScheduler shares chunks between concurrent jobs in proportion to their
+// weight (priority + 1). Each job's virtual time advances by 1/weight per chunk
+// served and the job furthest behind is picked, so no active job starves.
+type FairScheduler struct{}
+
+func (FairScheduler) Name() string {
+	return SchedulerFair
+}
+
+func (FairScheduler) Pick(candidates []*Job, now time.Time) *Job {
+	best := candidates[0]
+	for _, job := range candidates[1:] {
+		if job.VirtualTime < best.VirtualTime ||
+			(job.VirtualTime == best.VirtualTime && job.CreatedAt.Before(best.CreatedAt)) {
+			best = job
+		}
+	}
+	return best
+}
+
+// jobWeight is the share a job receives under fair scheduling
+func jobWeight(job *Job) float64 {
+	return float64(job.Priority + 1)
+}
+
+// TenantScheduler shares chunks equally between tenants and lets the wrapped
+// policy choose among the chosen tenant's jobs. Each tenant's virtual time
+// advances by one per chunk served. Tenants returning after being idle start no
+// further behind than the tenant served last, so they can't claim the cluster to
+// catch up.
+type TenantScheduler struct {
+	Policy Scheduler
+
+	virtualTime map[string]float64
+	clock       float64
+}
+
+func NewTenantScheduler(policy Scheduler) *TenantScheduler {
+	return &TenantScheduler{
+		Policy:      policy,
+		virtualTime: make(map[string]float64),
+	}
+}
+
+func (s *TenantScheduler) Name() string {
+	return s.Policy.Name()
+}
+
+// Pick charges the chosen tenant, so it must be called once per chunk leased
+func (s *TenantScheduler) Pick(candidates []*Job, now time.Time) *Job {
+	byTenant := make(map[string][]*Job)
+	var tenants []string
+	for _, job := range candidates {
+		if _, seen := byTenant[job.Tenant]; !seen {
+			tenants = append(tenants, job.Tenant)
+		}
+		byTenant[job.Tenant] = append(byTenant[job.Tenant], job)
+	}
+
+	best := tenants[0]
+	for _, tenant := range tenants {
+		if s.virtualTime[tenant] < s.clock {
+			s.virtualTime[tenant] = s.clock
+		}
+		if s.virtualTime[tenant] < s.virtualTime[best] {
+			best = tenant
+		}
+	}
+
+	s.clock = s.virtualTime[best]
+	s.virtualTime[best]++
+
+	return s.Policy.Pick(byTenant[best], now)
+}
@@ -0,0 +1,131 @@
+// Durable on-disk storage for completed chunk results. A worker writes every result
+// to its spool directory before submitting it and deletes the file only once the
+// coordinator has acknowledged it, so computed primes survive network failures and
+// worker restarts.
+
+package node
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+const (
+	spoolExtension   = ".json"
+	corruptExtension = ".corrupt" // appended to spool files that can't be decoded
+)
+
+type Spool struct {
+	Dir   string
+	Mutex sync.Mutex
+}
+
+func NewSpool(dir string) *Spool {
+	return &Spool{
+		Dir: dir,
+	}
+}
+
+// Save writes a result to the spool, replacing any earlier copy for the same chunk
+func (s *Spool) Save(result ChunkResult) error {
+	s.Mutex.Lock()
+	defer s.Mutex.Unlock()
+
+	if err := os.MkdirAll(s.Dir, 0755); err != nil {
+		return fmt.Errorf("failed to create spool directory - %v", err)
+	}
+
+	data, err := json.Marshal(result)
+	if err != nil {
+		return fmt.Errorf("failed to marshal result - %v", err)
+	}
+
+	// Write to a temporary file first so a crash never leaves a partial result behind
+	tmp, err := os.CreateTemp(s.Dir, ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create spool file - %v", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write spool file - %v", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to sync spool file - %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close spool file - %v", err)
+	}
+
+	if err := os.Rename(tmp.Name(), s.path(result.ChunkID)); err != nil {
+		return fmt.Errorf("failed to store spool file - %v", err)
+	}
+
+	return nil
+}
+
+// Remove deletes the spooled result for a chunk once it has been acknowledged
+func (s *Spool) Remove(chunkID string) error {
+	s.Mutex.Lock()
+	defer s.Mutex.Unlock()
+
+	if err := os.Remove(s.path(chunkID)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove spool file - %v", err)
+	}
+
+	return nil
+}
+
+// Pending returns every result still waiting to be acknowledged by the coordinator
+func (s *Spool) Pending() ([]ChunkResult, error) {
+	s.Mutex.Lock()
+	defer s.Mutex.Unlock()
+
+	entries, err := os.ReadDir(s.Dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read spool directory - %v", err)
+	}
+
+	var results []ChunkResult
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, spoolExtension) {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(s.Dir, name))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read spool file %s - %v", name, err)
+		}
+
+		// A corrupt file is moved aside, so it is reported once instead of on every
+		// retry, and kept for inspection
+		var result ChunkResult
+		if err := json.Unmarshal(data, &result); err != nil {
+			slog.Warn("Setting aside corrupt spool file", "file", name+corruptExtension, "error", err)
+			if err := os.Rename(filepath.Join(s.Dir, name), filepath.Join(s.Dir, name+corruptExtension)); err != nil {
+				slog.Error("Failed to set aside corrupt spool file", "file", name, "error", err)
+			}
+			continue
+		}
+
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+func (s *Spool) path(chunkID string) string {
+	return filepath.Join(s.Dir, url.PathEscape(chunkID)+spoolExtension)
+}
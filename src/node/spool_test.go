@@ -0,0 +1,35 @@
+package node
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPendingSetsAsideCorruptFiles(t *testing.T) {
+	spool := NewSpool(t.TempDir())
+	if err := spool.Save(ChunkResult{ChunkID: "chunk-1", Primes: []int{2, 3}}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	corrupt := filepath.Join(spool.Dir, "chunk-2"+spoolExtension)
+	if err := os.WriteFile(corrupt, []byte(`{"ChunkID": "chunk-2", "Pri`), 0o644); err != nil {
+		t.Fatalf("failed to write corrupt file: %v", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		pending, err := spool.Pending()
+		if err != nil {
+			t.Fatalf("Pending failed: %v", err)
+		}
+		if len(pending) != 1 || pending[0].ChunkID != "chunk-1" {
+			t.Errorf("Pending returned %+v, want only chunk-1", pending)
+		}
+	}
+
+	if _, err := os.Stat(corrupt); !os.IsNotExist(err) {
+		t.Errorf("corrupt file is still pending")
+	}
+	if _, err := os.Stat(corrupt + corruptExtension); err != nil {
+		t.Errorf("corrupt file wasn't kept aside: %v", err)
+	}
+}
@@ -0,0 +1,108 @@
+// Per-tenant quotas for the coordinator. Jobs are tagged with the tenant that
+// created them, and a tenant can be limited in how many jobs it runs at once, the
+// total range those jobs cover and the number of primes stored for it.
+
+package node
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+var ErrQuotaExceeded = errors.New("quota exceeded")
+
+// TenantQuota limits the work a tenant can request. Zero means unlimited.
+type TenantQuota struct {
+	MaxConcurrentJobs int
+	MaxRangeSize      int
+	MaxStoredPrimes   int
+}
+
+// TenantUsage is the share of the cluster a tenant currently uses
+type TenantUsage struct {
+	RunningJobs  int
+	RunningRange int
+	StoredPrimes int
+}
+
+// SetTenantQuota sets the limits that apply to new jobs of a tenant
+func (c *Coordinator) SetTenantQuota(tenant string, quota TenantQuota) {
+	c.Mutex.Lock()
+	defer c.Mutex.Unlock()
+
+	c.Quotas[tenant] = quota
+}
+
+// TenantUsage returns what a tenant's jobs currently use
+func (c *Coordinator) TenantUsage(tenant string) TenantUsage {
+	c.Mutex.Lock()
+	defer c.Mutex.Unlock()
+
+	return c.tenantUsage(tenant)
+}
+
+// tenantUsage adds up a tenant's jobs. Callers must hold the mutex.
+func (c *Coordinator) tenantUsage(tenant string) TenantUsage {
+	var usage TenantUsage
+	for _, job := range c.Jobs {
+		if job.Tenant != tenant {
+			continue
+		}
+
+		usage.StoredPrimes += job.PrimeCount
+		if !job.Terminal() {
+			usage.RunningJobs++
+			usage.RunningRange += job.End - job.Start + 1
+		}
+	}
+	return usage
+}
+
+// checkQuota rejects a job that would take its tenant over quota. As a job's prime
+// count is only known once it runs, its stored results are estimated from the
+// prime number theorem. Callers must hold the mutex.
+func (c *Coordinator) checkQuota(spec JobSpec) error {
+	quota, exists := c.Quotas[spec.Tenant]
+	if !exists {
+		return nil
+	}
+
+	usage := c.tenantUsage(spec.Tenant)
+
+	if quota.MaxConcurrentJobs > 0 && usage.RunningJobs+1 > quota.MaxConcurrentJobs {
+		return fmt.Errorf("%w: tenant %s already runs %d of %d jobs",
+			ErrQuotaExceeded, spec.Tenant, usage.RunningJobs, quota.MaxConcurrentJobs)
+	}
+
+	rangeSize := spec.End - spec.Start + 1
+	if quota.MaxRangeSize > 0 && usage.RunningRange+rangeSize > quota.MaxRangeSize {
+		return fmt.Errorf("%w: tenant %s would run a total range of %d, limit is %d",
+			ErrQuotaExceeded, spec.Tenant, usage.RunningRange+rangeSize, quota.MaxRangeSize)
+	}
+
+	estimate := estimatePrimeCount(spec.Start, spec.End)
+	if quota.MaxStoredPrimes > 0 && usage.StoredPrimes+estimate > quota.MaxStoredPrimes {
+		return fmt.Errorf("%w: tenant %s would store about %d primes, limit is %d",
+			ErrQuotaExceeded, spec.Tenant, usage.StoredPrimes+estimate, quota.MaxStoredPrimes)
+	}
+
+	return nil
+}
+
+// estimatePrimeCount approximates the number of primes in [start, end] using
+// pi(x) ~ x / ln x
+func estimatePrimeCount(start, end int) int {
+	pi := func(x int) float64 {
+		if x < 2 {
+			return 0
+		}
+		return float64(x) / math.Log(float64(x))
+	}
+
+	estimate := int(pi(end) - pi(start-1))
+	if estimate < 0 {
+		return 0
+	}
+	return estimate
+}
@@ -0,0 +1,98 @@
+// OpenTelemetry tracing. A job's trace starts with the request that created it, and
+// every lease of one of its chunks joins that trace, so the time a chunk spends
+// waiting, being computed on a worker and being submitted shows up under its job.
+// Trace context travels to the worker inside the leased chunk, comes back with the
+// result and is propagated on worker requests with W3C trace context headers.
+
+package node
+
+import (
+	"context"
+	"fmt"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("distributed-prime-number-generator/src/node")
+
+func init() {
+	// Propagation works even when spans aren't exported, so a worker without
+	// tracing still hands the coordinator's context back with its results
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+}
+
+// SetupTracing exports spans to the OTLP/HTTP collector at endpoint (host:port)
+// under the given service name. The returned function flushes and stops the
+// exporter.
+func SetupTracing(ctx context.Context, serviceName, endpoint string) (func(context.Context) error, error) {
+	exporter, err := otlptracehttp.New(ctx,
+		otlptracehttp.WithEndpoint(endpoint),
+		otlptracehttp.WithInsecure(),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create trace exporter - %v", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL,
+			semconv.ServiceName(serviceName),
+			semconv.ServiceVersion(Version),
+		)),
+	)
+	otel.SetTracerProvider(provider)
+
+	return provider.Shutdown, nil
+}
+
+// InjectTraceContext returns the trace context of ctx in a form that can be sent
+// with a chunk or a result
+func InjectTraceContext(ctx context.Context) map[string]string {
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	if len(carrier) == 0 {
+		return nil
+	}
+	return carrier
+}
+
+// ExtractTraceContext returns ctx joined to the trace context carried by a chunk or
+// a result
+func ExtractTraceContext(ctx context.Context, carrier map[string]string) context.Context {
+	return otel.GetTextMapPropagator().Extract(ctx, propagation.MapCarrier(carrier))
+}
+
+// endSpan records the outcome of an operation on its span and ends it
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+func jobAttributes(job *Job) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("job.id", job.ID),
+		attribute.Int("job.start", job.Start),
+		attribute.Int("job.end", job.End),
+		attribute.String("job.tenant", job.Tenant),
+	}
+}
+
+func chunkAttributes(chunk *WorkChunk) []attribute.KeyValue {
+	return []attribute.KeyValue{
+		attribute.String("job.id", chunk.JobID),
+		attribute.String("chunk.id", chunk.ID),
+		attribute.Int("chunk.start", chunk.Start),
+		attribute.Int("chunk.end", chunk.End),
+		attribute.String("chunk.algorithm", string(chunk.Algorithm)),
+	}
+}
@@ -0,0 +1,288 @@
+// Job webhooks. A job can name callback URLs when it is created, and once it
+// completes, fails or is cancelled the coordinator POSTs its summary to each of
+// them. Requests are signed with HMAC-SHA256 so receivers can check they came from
+// the coordinator, and failed deliveries are retried with exponential backoff.
+// Callbacks may not reach loopback, private or link-local addresses, such as the
+// coordinator's own host or a cloud metadata service, unless the operator allows
+// their network; this is checked when a job is created and again on every dial,
+// after the callback's host has been resolved.
+
+package node
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"distributed-prime-number-generator/src/models"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/netip"
+	"net/url"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	MaxCallbacks            = 5 // callback URLs a job may name
+	WebhookMaxAttempts      = 6
+	WebhookInitialBackoff   = 2 * time.Second
+	WebhookMaxBackoff       = 5 * time.Minute
+	WebhookTimeout          = 10 * time.Second
+	WebhookSignatureHeader  = "X-Prime-Signature"
+	WebhookTimestampHeader  = "X-Prime-Timestamp"
+	WebhookDeliveryIDHeader = "X-Prime-Delivery"
+)
+
+var (
+	ErrInvalidCallback  = errors.New("invalid callback")
+	ErrWebhooksDisabled = errors.New("webhooks are not enabled on this server")
+	ErrBlockedCallback  = errors.New("callback address is not allowed")
+)
+
+type DeliveryState = models.DeliveryState
+
+const (
+	DeliveryPending   = models.DeliveryPending
+	DeliveryDelivered = models.DeliveryDelivered
+	DeliveryFailed    = models.DeliveryFailed // gave up after the last attempt or a rejection
+)
+
+// WebhookDelivery tracks sending a job's summary to one of its callback URLs
+type WebhookDelivery = models.WebhookDelivery
+
+// WebhookPayload is the body POSTed to a callback URL
+type WebhookPayload struct {
+	Event EventType  `json:"event"`
+	Job   JobSummary `json:"job"`
+}
+
+// WebhookNotifier signs and sends webhook requests
+type WebhookNotifier struct {
+	Secret          []byte
+	Client          *http.Client
+	MaxAttempts     int
+	InitialBackoff  time.Duration
+	MaxBackoff      time.Duration
+	AllowedNetworks []netip.Prefix // internal networks callbacks may reach anyway
+}
+
+// NewWebhookNotifier returns a notifier whose client refuses to connect to
+// addresses that aren't allowed. The client ignores proxy settings, since a proxy
+// would do the connecting in its place.
+func NewWebhookNotifier(secret []byte) *WebhookNotifier {
+	notifier := &WebhookNotifier{
+		Secret:         secret,
+		MaxAttempts:    WebhookMaxAttempts,
+		InitialBackoff: WebhookInitialBackoff,
+		MaxBackoff:     WebhookMaxBackoff,
+	}
+
+	dialer := &net.Dialer{Timeout: WebhookTimeout, Control: notifier.checkDial}
+	notifier.Client = &http.Client{
+		Timeout:   WebhookTimeout,
+		Transport: &http.Transport{DialContext: dialer.DialContext, TLSHandshakeTimeout: WebhookTimeout},
+	}
+	return notifier
+}
+
+// ParseNetworks parses a comma-separated list of CIDR prefixes or single addresses
+func ParseNetworks(list string) ([]netip.Prefix, error) {
+	var networks []netip.Prefix
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if addr, err := netip.ParseAddr(entry); err == nil {
+			networks = append(networks, netip.PrefixFrom(addr, addr.BitLen()))
+			continue
+		}
+		network, err := netip.ParsePrefix(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid network %q - %v", entry, err)
+		}
+		networks = append(networks, network.Masked())
+	}
+	return networks, nil
+}
+
+// allows reports whether callbacks may be delivered to an address: any public
+// address, and internal ones only in an allowed network
+func (n *WebhookNotifier) allows(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	for _, network := range n.AllowedNetworks {
+		if network.Contains(addr) {
+			return true
+		}
+	}
+	return !(addr.IsLoopback() || addr.IsPrivate() || addr.IsUnspecified() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() || addr.IsMulticast())
+}
+
+// checkDial refuses connections to addresses that aren't allowed. It runs once the
+// callback's host has been resolved, so a name can't smuggle in an internal address.
+func (n *WebhookNotifier) checkDial(network, address string, _ syscall.RawConn) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrBlockedCallback, address)
+	}
+	if !n.allows(addrPort.Addr()) {
+		return fmt.Errorf("%w: %s", ErrBlockedCallback, addrPort.Addr())
+	}
+	return nil
+}
+
+// SignWebhook returns the signature of a webhook body sent at the given Unix
+// time: the hex HMAC-SHA256 of "{timestamp}.{body}", prefixed with "sha256="
+func SignWebhook(secret []byte, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// validateCallbacks checks that a job's callback URLs can be delivered to. Hosts
+// given as internal addresses or localhost are refused up front; names that resolve
+// to internal addresses are caught when delivering.
+func (c *Coordinator) validateCallbacks(callbacks []string) error {
+	if len(callbacks) == 0 {
+		return nil
+	}
+	if c.Webhooks == nil {
+		return ErrWebhooksDisabled
+	}
+	if len(callbacks) > MaxCallbacks {
+		return fmt.Errorf("%w: at most %d callbacks are allowed", ErrInvalidCallback, MaxCallbacks)
+	}
+
+	for _, callback := range callbacks {
+		parsed, err := url.Parse(callback)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("%w: %q is not an absolute http or https URL", ErrInvalidCallback, callback)
+		}
+
+		host := strings.ToLower(strings.TrimSuffix(parsed.Hostname(), "."))
+		if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+			host = "127.0.0.1"
+		}
+		if addr, err := netip.ParseAddr(host); err == nil && !c.Webhooks.allows(addr) {
+			return fmt.Errorf("%w: %q points at an internal address", ErrInvalidCallback, callback)
+		}
+	}
+	return nil
+}
+
+// notifyCallbacks starts delivering a finished job's summary to its callback URLs.
+// Callers must hold the mutex.
+func (c *Coordinator) notifyCallbacks(job *Job, event EventType) {
+	if len(job.Callbacks) == 0 || c.Webhooks == nil {
+		return
+	}
+
+	// Deliveries are added before summarizing, so the payload shows them as pending
+	job.Deliveries = make([]*WebhookDelivery, len(job.Callbacks))
+	for i, callback := range job.Callbacks {
+		job.Deliveries[i] = &WebhookDelivery{URL: callback, State: DeliveryPending}
+	}
+
+	body, err := json.Marshal(WebhookPayload{Event: event, Job: c.summarizeJob(job)})
+	if err != nil {
+		slog.Error("Failed to encode webhook payload", "job_id", job.ID, "error", err)
+		return
+	}
+
+	for i, delivery := range job.Deliveries {
+		deliveryID := fmt.Sprintf("%s-%d", job.ID, i)
+		go c.deliverWebhook(job.ID, deliveryID, delivery, body)
+	}
+}
+
+// deliverWebhook sends a payload to one callback URL, retrying with exponential
+// backoff until it is accepted, rejected or out of attempts
+func (c *Coordinator) deliverWebhook(jobID, deliveryID string, delivery *WebhookDelivery, body []byte) {
+	notifier := c.Webhooks
+	backoff := notifier.InitialBackoff
+
+	for attempt := 1; ; attempt++ {
+		retry, err := notifier.send(delivery.URL, deliveryID, body)
+
+		now := time.Now()
+		c.Mutex.Lock()
+		delivery.Attempts = attempt
+		delivery.LastAttempt = &now
+		switch {
+		case err == nil:
+			delivery.State = DeliveryDelivered
+			delivery.DeliveredAt = &now
+			delivery.LastError = ""
+		case !retry || attempt >= notifier.MaxAttempts:
+			delivery.State = DeliveryFailed
+			delivery.LastError = err.Error()
+		default:
+			delivery.LastError = err.Error()
+		}
+		state := delivery.State
+		c.Mutex.Unlock()
+
+		switch state {
+		case DeliveryDelivered:
+			slog.Info("Delivered webhook", "job_id", jobID, "url", delivery.URL, "attempts", attempt)
+			return
+		case DeliveryFailed:
+			slog.Warn("Giving up on webhook", "job_id", jobID, "url", delivery.URL, "attempts", attempt, "error", err)
+			return
+		}
+
+		slog.Debug("Webhook delivery failed, retrying", "job_id", jobID, "url", delivery.URL,
+			"attempt", attempt, "backoff", backoff, "error", err)
+		time.Sleep(backoff)
+		backoff = min(backoff*2, notifier.MaxBackoff)
+	}
+}
+
+// send makes a single signed delivery attempt. The returned flag reports whether a
+// failure is worth retrying: network errors, timeouts, 429s and server errors are,
+// other rejections are not.
+func (n *WebhookNotifier) send(callback, deliveryID string, body []byte) (bool, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), WebhookTimeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, callback, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("failed to create request - %v", err)
+	}
+
+	timestamp := time.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "prime-coordinator/"+Version)
+	req.Header.Set(WebhookDeliveryIDHeader, deliveryID)
+	req.Header.Set(WebhookTimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(WebhookSignatureHeader, SignWebhook(n.Secret, timestamp, body))
+
+	resp, err := n.Client.Do(req)
+	if errors.Is(err, ErrBlockedCallback) {
+		return false, fmt.Errorf("failed to send request - %w", err)
+	}
+	if err != nil {
+		return true, fmt.Errorf("failed to send request - %v", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError
+	return retry, fmt.Errorf("callback responded with %s", resp.Status)
+}
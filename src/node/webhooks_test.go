@@ -0,0 +1,212 @@
+package node
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestSignWebhook(t *testing.T) {
+	got := SignWebhook([]byte("secret"), 1700000000, []byte(`{"event":"job-completed"}`))
+	want := "sha256=4ce54d0773304d6fcf780166770b2efed847c9ea9391240d824d2ba564e7ec4b"
+	if got != want {
+		t.Errorf("SignWebhook returned %s, want %s", got, want)
+	}
+}
+
+// webhookReceiver records the requests it gets and answers them with the given
+// statuses in turn, then with 204s
+type webhookReceiver struct {
+	mutex    sync.Mutex
+	statuses []int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (r *webhookReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.requests = append(r.requests, req)
+	r.bodies = append(r.bodies, body)
+
+	status := http.StatusNoContent
+	if len(r.statuses) > 0 {
+		status, r.statuses = r.statuses[0], r.statuses[1:]
+	}
+	w.WriteHeader(status)
+}
+
+// newWebhookCoordinator returns a coordinator whose webhooks may reach test servers
+// on loopback and are retried without waiting long
+func newWebhookCoordinator() *Coordinator {
+	c := NewCoordinator()
+	c.Webhooks = NewWebhookNotifier([]byte("secret"))
+	c.Webhooks.AllowedNetworks = []netip.Prefix{netip.MustParsePrefix("127.0.0.0/8")}
+	c.Webhooks.InitialBackoff = time.Millisecond
+	c.Webhooks.MaxBackoff = 5 * time.Millisecond
+	c.Webhooks.MaxAttempts = 3
+	return c
+}
+
+// finishedDelivery waits for a job's only webhook delivery to stop being pending
+func finishedDelivery(t *testing.T, c *Coordinator, jobID string) WebhookDelivery {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		summary, err := c.JobSummary(jobID)
+		if err != nil {
+			t.Fatalf("JobSummary failed: %v", err)
+		}
+		if len(summary.Webhooks) != 1 {
+			t.Fatalf("job has %d webhook deliveries, want 1", len(summary.Webhooks))
+		}
+		if summary.Webhooks[0].State != DeliveryPending {
+			return summary.Webhooks[0]
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatalf("webhook delivery still pending")
+	return WebhookDelivery{}
+}
+
+func TestWebhookDelivery(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int
+		state    DeliveryState
+		attempts int
+	}{
+		{"accepted", nil, DeliveryDelivered, 1},
+		{"retried after server errors", []int{http.StatusInternalServerError, http.StatusTooManyRequests}, DeliveryDelivered, 3},
+		{"rejected", []int{http.StatusBadRequest}, DeliveryFailed, 1},
+		{"out of attempts", []int{500, 500, 500, 500}, DeliveryFailed, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			receiver := &webhookReceiver{statuses: tt.statuses}
+			ts := httptest.NewServer(receiver)
+			defer ts.Close()
+
+			c := newWebhookCoordinator()
+			jobID, err := c.CreateJob(context.Background(), JobSpec{Start: 2, End: 20, ChunkSize: 100, Callbacks: []string{ts.URL}})
+			if err != nil {
+				t.Fatalf("CreateJob failed: %v", err)
+			}
+			if err := c.CancelJob(jobID); err != nil {
+				t.Fatalf("CancelJob failed: %v", err)
+			}
+
+			delivery := finishedDelivery(t, c, jobID)
+			if delivery.State != tt.state || delivery.Attempts != tt.attempts {
+				t.Errorf("delivery is %s after %d attempts, want %s after %d", delivery.State, delivery.Attempts, tt.state, tt.attempts)
+			}
+			if (delivery.DeliveredAt != nil) != (tt.state == DeliveryDelivered) || (delivery.LastError == "") != (tt.state == DeliveryDelivered) {
+				t.Errorf("delivery %+v doesn't record its outcome", delivery)
+			}
+
+			receiver.mutex.Lock()
+			defer receiver.mutex.Unlock()
+			if len(receiver.requests) != tt.attempts {
+				t.Fatalf("receiver got %d requests, want %d", len(receiver.requests), tt.attempts)
+			}
+			for i, req := range receiver.requests {
+				timestamp, err := strconv.ParseInt(req.Header.Get(WebhookTimestampHeader), 10, 64)
+				if err != nil {
+					t.Fatalf("request %d has timestamp %q", i, req.Header.Get(WebhookTimestampHeader))
+				}
+				if got, want := req.Header.Get(WebhookSignatureHeader), SignWebhook([]byte("secret"), timestamp, receiver.bodies[i]); got != want {
+					t.Errorf("request %d is signed %s, want %s", i, got, want)
+				}
+				if id := req.Header.Get(WebhookDeliveryIDHeader); id != jobID+"-0" {
+					t.Errorf("request %d has delivery ID %q, want %q", i, id, jobID+"-0")
+				}
+
+				var payload WebhookPayload
+				if err := json.Unmarshal(receiver.bodies[i], &payload); err != nil {
+					t.Fatalf("failed to decode payload: %v", err)
+				}
+				if payload.Job.ID != jobID || payload.Job.State != JobCancelled {
+					t.Errorf("payload is about job %s in state %s, want %s in %s", payload.Job.ID, payload.Job.State, jobID, JobCancelled)
+				}
+			}
+		})
+	}
+}
+
+func TestInternalCallbacksAreRefused(t *testing.T) {
+	tests := []struct {
+		callback string
+		allowed  bool
+	}{
+		{"http://127.0.0.1:8080/hook", false},
+		{"http://localhost/hook", false},
+		{"http://169.254.169.254/latest/meta-data", false},
+		{"http://10.0.0.5/hook", false},
+		{"http://[::1]/hook", false},
+		{"http://[::ffff:192.168.1.1]/hook", false},
+		{"http://10.1.2.3/hook", true}, // in the allowed network
+		{"https://example.com/hook", true},
+		{"https://93.184.216.34/hook", true},
+	}
+	c := NewCoordinator()
+	c.Webhooks = NewWebhookNotifier([]byte("secret"))
+	c.Webhooks.AllowedNetworks = []netip.Prefix{netip.MustParsePrefix("10.1.0.0/16")}
+
+	for _, tt := range tests {
+		t.Run(tt.callback, func(t *testing.T) {
+			err := c.validateCallbacks([]string{tt.callback})
+			if tt.allowed && err != nil {
+				t.Errorf("validateCallbacks failed: %v", err)
+			}
+			if !tt.allowed && !errors.Is(err, ErrInvalidCallback) {
+				t.Errorf("validateCallbacks returned %v, want %v", err, ErrInvalidCallback)
+			}
+		})
+	}
+}
+
+func TestDeliveriesToInternalAddressesAreNotAttempted(t *testing.T) {
+	receiver := &webhookReceiver{}
+	ts := httptest.NewServer(receiver)
+	defer ts.Close()
+
+	// The URL passed validation under another name, but resolves to loopback
+	retry, err := NewWebhookNotifier([]byte("secret")).send(ts.URL, "delivery", []byte("{}"))
+	if !errors.Is(err, ErrBlockedCallback) || retry {
+		t.Errorf("send returned %v, %v, want %v without retrying", retry, err, ErrBlockedCallback)
+	}
+	if len(receiver.requests) != 0 {
+		t.Errorf("receiver got %d requests, want none", len(receiver.requests))
+	}
+}
+
+func TestParseNetworks(t *testing.T) {
+	networks, err := ParseNetworks("10.0.0.0/8, 192.168.5.20,fd00::/8")
+	if err != nil {
+		t.Fatalf("ParseNetworks failed: %v", err)
+	}
+	want := []string{"10.0.0.0/8", "192.168.5.20/32", "fd00::/8"}
+	if len(networks) != len(want) {
+		t.Fatalf("ParseNetworks returned %v, want %v", networks, want)
+	}
+	for i, network := range networks {
+		if network.String() != want[i] {
+			t.Errorf("network %d is %s, want %s", i, network, want[i])
+		}
+	}
+
+	if _, err := ParseNetworks("10.0.0.0/33"); err == nil {
+		t.Errorf("ParseNetworks accepted an invalid prefix")
+	}
+}
@@ -7,31 +7,99 @@ package node
 
 import (
 	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"distributed-prime-number-generator/src/algorithms"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"io"
 	"io/ioutil"
+	"log/slog"
 	"net/http"
+	"os"
+	"sync"
 	"time"
 )
 
+const spoolRetryInterval = 5 * time.Second
+
+// errResultRejected marks results the server will never accept, those from expired
+// leases or with a bad digest, so they are dropped instead of being retried forever.
+// Anything else, including credentials the server refuses, keeps the result spooled.
+var errResultRejected = errors.New("result rejected")
+
+// errDrained is returned when the server asks the worker to stop taking work, and
+// errUnregistered when the server no longer knows the worker, e.g. after eviction
+var (
+	errDrained      = errors.New("worker drained")
+	errUnregistered = errors.New("worker is no longer registered")
+)
+
 type Worker struct {
 	ID            string
 	ServerURL     string
 	Client        *http.Client
+	Spool         *Spool
+	APIKey        string // sent with every request when the server requires worker credentials
+
+	flushMutex    sync.Mutex
+	metrics       *workerMetrics
 }
 
-func NewWorker(serverURL string) *Worker {
+func NewWorker(serverURL, spoolDir string) *Worker {
 	return &Worker{
 		ID:        "",  // Will be assigned by the server upon registration
 		ServerURL: serverURL,
 		Client:    &http.Client{Timeout: 10 * time.Second},
+		Spool:     NewSpool(spoolDir),
+		metrics:   newWorkerMetrics(),
 	}
 }
 
+// UseTLS configures the worker to verify the server against a CA bundle and, when a
+// certificate and key are given, to authenticate itself with them. The server then
+// derives the worker's ID from the certificate.
+func (w *Worker) UseTLS(caFile, certFile, keyFile string) error {
+	config := &tls.Config{MinVersion: tls.VersionTLS12}
+	
+	if caFile != "" {
+		data, err := os.ReadFile(caFile)
+		if err != nil {
+			return fmt.Errorf("failed to read CA bundle - %v", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return fmt.Errorf("no certificates found in CA bundle %s", caFile)
+		}
+		config.RootCAs = pool
+	}
+	
+	if certFile != "" || keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+		if err != nil {
+			return fmt.Errorf("failed to load client certificate - %v", err)
+		}
+		config.Certificates = []tls.Certificate{cert}
+	}
+	
+	w.Client.Transport = &http.Transport{TLSClientConfig: config}
+	return nil
+}
+
 // Register registers this worker with the server
 func (w *Worker) Register() error {
-	resp, err := w.Client.Post(w.ServerURL+"/api/workers", "application/json", nil)
+	jsonData, err := json.Marshal(map[string]string{"version": Version})
+	if err != nil {
+		return fmt.Errorf("failed to marshal registration - %v", err)
+	}
+	
+	resp, err := w.send(context.Background(), http.MethodPost, w.ServerURL+"/api/v1/workers", bytes.NewBuffer(jsonData))
 	if err != nil {
 		return fmt.Errorf("registration failed - %v", err)
 	}
@@ -52,22 +120,29 @@ func (w *Worker) Register() error {
 	}
 	
 	w.ID = result["workerId"]
-	fmt.Printf("Worker registered with ID: %s\n", w.ID)
+	slog.Info("Worker registered", "worker_id", w.ID, "version", Version)
 	return nil
 }
 
 // GetNextChunk requests the next available chunk from the server
 func (w *Worker) GetNextChunk() (*WorkChunk, error) {
-	url := fmt.Sprintf("%s/api/workers/%s/chunks", w.ServerURL, w.ID)
+	url := fmt.Sprintf("%s/api/v1/workers/%s/chunks", w.ServerURL, w.ID)
 
-	resp, err := w.Client.Get(url)
+	startTime := time.Now()
+	resp, err := w.send(context.Background(), http.MethodGet, url, nil)
+	w.metrics.fetchDuration.Observe(time.Since(startTime).Seconds())
 	if err != nil {
 		return nil, fmt.Errorf("failed to get chunk - %v", err)
 	}
 	defer resp.Body.Close()
 	
-	if resp.StatusCode == http.StatusNoContent {
+	switch resp.StatusCode {
+	case http.StatusNoContent:
 		return nil, nil
+	case http.StatusGone:
+		return nil, errDrained
+	case http.StatusNotFound:
+		return nil, fmt.Errorf("%w: %s", errUnregistered, w.ID)
 	}
 	
 	if resp.StatusCode != http.StatusOK {
@@ -87,9 +162,19 @@ func (w *Worker) GetNextChunk() (*WorkChunk, error) {
 	return &chunk, nil
 }
 
-// SubmitResult sends the calculation result back to the server
-func (w *Worker) SubmitResult(result ChunkResult) error {
-	url := fmt.Sprintf("%s/api/workers/%s/results", w.ServerURL, w.ID)
+// SubmitResult sends the calculation result back to the server on behalf of the
+// worker that computed it. The submission is traced under the chunk's lease.
+func (w *Worker) SubmitResult(result ChunkResult) (err error) {
+	ctx, span := tracer.Start(ExtractTraceContext(context.Background(), result.TraceContext), "Worker.SubmitResult",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("chunk.id", result.ChunkID), attribute.String("worker.id", result.WorkerID)))
+	defer func() { endSpan(span, err) }()
+	
+	workerID := result.WorkerID
+	if workerID == "" {
+		workerID = w.ID
+	}
+	url := fmt.Sprintf("%s/api/v1/workers/%s/results", w.ServerURL, workerID)
 	
 	jsonData, err := json.Marshal(result)
 	if err != nil {
@@ -97,86 +182,208 @@ func (w *Worker) SubmitResult(result ChunkResult) error {
 	}
 	
 	// Post the result
-	resp, err := w.Client.Post(url, "application/json", bytes.NewBuffer(jsonData))
+	resp, err := w.send(ctx, http.MethodPost, url, bytes.NewBuffer(jsonData))
 	if err != nil {
 		return fmt.Errorf("failed to submit result - %v", err)
 	}
 	defer resp.Body.Close()
 	
-	if resp.StatusCode != http.StatusOK {
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return nil
+	case http.StatusConflict, http.StatusUnprocessableEntity:
+		return fmt.Errorf("%w for chunk %s with status - %d", errResultRejected, result.ChunkID, resp.StatusCode)
+	case http.StatusUnauthorized, http.StatusForbidden, http.StatusNotFound:
+		return fmt.Errorf("submit result for chunk %s refused with status - %d", result.ChunkID, resp.StatusCode)
+	default:
 		return fmt.Errorf("submit result failed with status - %d", resp.StatusCode)
 	}
+}
+
+// send performs a request against the server with the worker's credentials and the
+// trace context of ctx
+func (w *Worker) send(ctx context.Context, method, url string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return nil, err
+	}
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
 	
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if w.APIKey != "" {
+		req.Header.Set("X-API-Key", w.APIKey)
+	}
+	
+	return w.Client.Do(req)
+}
+
+// FlushSpool submits every spooled result and removes those the server acknowledges.
+// It returns the first submission error encountered, leaving the rest spooled.
+func (w *Worker) FlushSpool() error {
+	w.flushMutex.Lock()
+	defer w.flushMutex.Unlock()
+
+	results, err := w.Spool.Pending()
+	if err != nil {
+		return err
+	}
+
+	for _, result := range results {
+		if err := w.SubmitResult(result); err != nil {
+			if !errors.Is(err, errResultRejected) {
+				return err
+			}
+			slog.Warn("Discarding spooled result", "chunk_id", result.ChunkID, "error", err)
+		}
+
+		if err := w.Spool.Remove(result.ChunkID); err != nil {
+			slog.Error("Failed to remove spooled result", "chunk_id", result.ChunkID, "error", err)
+		}
+	}
+
 	return nil
 }
 
-// ProcessChunk handles the calculation of primes in a given chunk
+// retrySpooled periodically resubmits results that could not be delivered earlier,
+// until the context is cancelled
+func (w *Worker) retrySpooled(ctx context.Context) {
+	ticker := time.NewTicker(spoolRetryInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		if err := w.FlushSpool(); err != nil {
+			w.metrics.retries.WithLabelValues("submit").Inc()
+			slog.Warn("Failed to resubmit spooled results", "error", err, "retry_in", spoolRetryInterval)
+		}
+	}
+}
+
+// ProcessChunk handles the calculation of primes in a given chunk. The work is
+// traced under the chunk's lease.
 func (w *Worker) ProcessChunk(chunk *WorkChunk) (*ChunkResult, error) {
+	_, span := tracer.Start(ExtractTraceContext(context.Background(), chunk.TraceContext), "Worker.ProcessChunk",
+		trace.WithAttributes(chunkAttributes(chunk)...),
+		trace.WithAttributes(attribute.String("worker.id", w.ID)))
 	startTime := time.Now()
 	
 	var primes []int
 	var err error
 
+	slog.Debug("Processing chunk", "worker_id", w.ID, "job_id", chunk.JobID, "chunk_id", chunk.ID,
+		"algorithm", chunk.Algorithm)
+
 	if chunk.Algorithm == SOE {
-		fmt.Printf("Worker %s processing chunk %s with Sieve of Eratosthenes\n", w.ID, chunk.ID)
 		primes, err = algorithms.FindPrimesWithEratosthenes(chunk.Start, chunk.End)
 	} else {
-		fmt.Printf("Worker %s processing chunk %s with Miller-Rabin\n", w.ID, chunk.ID)
 		primes, err = algorithms.FindPrimesWithMillerRabin(chunk.Start, chunk.End, chunk.Rounds)
 	}
 	
 	if err != nil {
-		return nil, fmt.Errorf("error processing chunk - %v", err)
+		err = fmt.Errorf("error processing chunk - %v", err)
+		endSpan(span, err)
+		return nil, err
 	}
 	
 	runtime := time.Since(startTime)
+	span.SetAttributes(attribute.Int("chunk.primes", len(primes)))
+	span.End()
+	w.metrics.chunkDuration.WithLabelValues(string(chunk.Algorithm)).Observe(runtime.Seconds())
 	
 	result := &ChunkResult{
-		ChunkID: chunk.ID,
-		Primes:  primes,
-		Runtime: runtime,
+		ChunkID:  chunk.ID,
+		WorkerID: w.ID,
+		Token:    chunk.Token,
+		Primes:   primes,
+		Digest:   ResultDigest(chunk.ID, primes),
+		Runtime:  runtime,
+		TraceContext: chunk.TraceContext,
 	}
 	
-	fmt.Printf("Worker %s finished chunk %s (found %d primes in %v)\n", 
-		w.ID, chunk.ID, len(primes), runtime)
+	slog.Debug("Finished chunk", "worker_id", w.ID, "job_id", chunk.JobID, "chunk_id", chunk.ID,
+		"primes", len(primes), "runtime", runtime)
 	
 	return result, nil
 }
 
-// Run starts the worker's processing loop and keeps it running
+// Run starts the worker's processing loop and keeps it running until the server
+// drains the worker, which returns nil once spooled results have been delivered
 func (w *Worker) Run() error {
-    fmt.Printf("Worker starting, connecting to %s\n", w.ServerURL)
+    slog.Info("Worker starting", "server", w.ServerURL)
     
     if err := w.Register(); err != nil {
         return err
     }
     
+    // Results spooled before a restart are delivered alongside new work until Run
+    // returns. Stopping waits for a retry in progress, so the final flush runs alone.
+    ctx, cancel := context.WithCancel(context.Background())
+    retrying := make(chan struct{})
+    go func() {
+        defer close(retrying)
+        w.retrySpooled(ctx)
+    }()
+    stopRetrying := func() {
+        cancel()
+        <-retrying
+    }
+    defer stopRetrying()
+    
     for {
         chunk, err := w.GetNextChunk()
+        if errors.Is(err, errDrained) {
+            slog.Info("Worker drained by the server, exiting", "worker_id", w.ID)
+            stopRetrying()
+            if err := w.FlushSpool(); err != nil {
+                slog.Error("Failed to submit spooled results", "error", err, "spool", w.Spool.Dir)
+            }
+            return nil
+        }
+        if errors.Is(err, errUnregistered) {
+            return err
+        }
         if err != nil {
-            fmt.Printf("Error getting chunk: %v - will retry in 5 seconds\n", err)
+            w.metrics.retries.WithLabelValues("fetch").Inc()
+            slog.Warn("Failed to get chunk", "worker_id", w.ID, "error", err, "retry_in", 5*time.Second)
             time.Sleep(5 * time.Second)
             continue
         }
         
         // No chunks available - wait and try again
         if chunk == nil {
-            // fmt.Printf("Worker %s: no work available, waiting for 5 seconds...\n", w.ID)
+            slog.Debug("No work available", "worker_id", w.ID, "retry_in", 5*time.Second)
             time.Sleep(5 * time.Second)
             continue
         }
         
         result, err := w.ProcessChunk(chunk)
         if err != nil {
-            fmt.Printf("Error processing chunk: %v - will retry in 5 seconds\n", err)
+            w.metrics.retries.WithLabelValues("process").Inc()
+            slog.Error("Failed to process chunk", "worker_id", w.ID, "chunk_id", chunk.ID, "error", err,
+                "retry_in", 5*time.Second)
             time.Sleep(5 * time.Second)
             continue
         }
         
-        if err := w.SubmitResult(*result); err != nil {
-            fmt.Printf("Error submitting result: %v - will retry in 5 seconds\n", err)
-            time.Sleep(5 * time.Second)
+        // Persist the result before submitting so it is never lost
+        if err := w.Spool.Save(*result); err != nil {
+            slog.Error("Failed to spool result, submitting directly", "chunk_id", result.ChunkID, "error", err)
+            if err := w.SubmitResult(*result); err != nil {
+                slog.Error("Failed to submit result, result lost", "chunk_id", result.ChunkID, "error", err)
+            }
             continue
         }
+        
+        if err := w.FlushSpool(); err != nil {
+            w.metrics.retries.WithLabelValues("submit").Inc()
+            slog.Warn("Failed to submit result, spooled for retry", "chunk_id", result.ChunkID, "error", err)
+        }
     }
 }
\ No newline at end of file
@@ -0,0 +1,56 @@
+package node
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFlushSpoolKeepsResultsUnlessRejected(t *testing.T) {
+	tests := []struct {
+		status   int
+		rejected bool
+		kept     bool
+	}{
+		{http.StatusOK, false, false},
+		{http.StatusConflict, true, false},
+		{http.StatusUnprocessableEntity, true, false},
+		{http.StatusUnauthorized, false, true},
+		{http.StatusForbidden, false, true},
+		{http.StatusNotFound, false, true},
+		{http.StatusInternalServerError, false, true},
+	}
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+			}))
+			defer ts.Close()
+
+			worker := NewWorker(ts.URL, t.TempDir())
+			worker.ID = "worker-a"
+			result := ChunkResult{ChunkID: "chunk-1", WorkerID: "worker-a", Primes: []int{2, 3}}
+			if err := worker.Spool.Save(result); err != nil {
+				t.Fatalf("Save failed: %v", err)
+			}
+
+			err := worker.SubmitResult(result)
+			if errors.Is(err, errResultRejected) != tt.rejected {
+				t.Errorf("SubmitResult returned %v, rejected should be %v", err, tt.rejected)
+			}
+
+			err = worker.FlushSpool()
+			if (err != nil) != tt.kept {
+				t.Errorf("FlushSpool returned %v", err)
+			}
+			pending, err := worker.Spool.Pending()
+			if err != nil {
+				t.Fatalf("Pending failed: %v", err)
+			}
+			if kept := len(pending) == 1; kept != tt.kept {
+				t.Errorf("result kept in spool: %v, want %v", kept, tt.kept)
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+// Worker management for the coordinator. Lists the workers in the pool with their
+// activity and throughput, and lets operators drain a worker, so it finishes its
+// current chunks and then exits, or evict one, so its chunks are reassigned at once.
+
+package node
+
+import (
+	"distributed-prime-number-generator/src/models"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+)
+
+// Version identifies the build of the worker and server binaries. Release builds
+// set it with -ldflags "-X distributed-prime-number-generator/src/node.Version=...".
+var Version = "dev"
+
+type WorkerState = models.WorkerState
+
+const (
+	WorkerActive   = models.WorkerActive
+	WorkerDraining = models.WorkerDraining // finishing its current chunks
+	WorkerDrained  = models.WorkerDrained  // draining with nothing left in flight
+	WorkerLost     = models.WorkerLost     // not heard from for WorkerStaleAfter
+)
+
+var (
+	ErrUnknownWorker  = errors.New("unknown worker")
+	ErrWorkerDraining = errors.New("worker is draining")
+)
+
+// WorkerSummary is the overview of a worker returned by listings
+type WorkerSummary = models.WorkerSummary
+
+// ListWorkers returns every worker in the pool, ordered by ID
+func (c *Coordinator) ListWorkers() []WorkerSummary {
+	c.Mutex.Lock()
+	defer c.Mutex.Unlock()
+
+	workers := make([]WorkerSummary, 0, len(c.Workers))
+	for _, worker := range c.Workers {
+		workers = append(workers, summarizeWorker(worker))
+	}
+	sort.Slice(workers, func(i, j int) bool {
+		return workers[i].ID < workers[j].ID
+	})
+
+	return workers
+}
+
+// WorkerSummary returns the overview of a single worker
+func (c *Coordinator) WorkerSummary(workerID string) (*WorkerSummary, error) {
+	c.Mutex.Lock()
+	defer c.Mutex.Unlock()
+
+	worker, exists := c.Workers[workerID]
+	if !exists {
+		return nil, fmt.Errorf("%w (%s)", ErrUnknownWorker, workerID)
+	}
+
+	summary := summarizeWorker(worker)
+	return &summary, nil
+}
+
+// WorkerOwner returns the principal that registered a worker, and whether the
+// worker is registered at all
+func (c *Coordinator) WorkerOwner(workerID string) (string, bool) {
+	c.Mutex.Lock()
+	defer c.Mutex.Unlock()
+
+	worker, exists := c.Workers[workerID]
+	if !exists {
+		return "", false
+	}
+	return worker.Owner, true
+}
+
+// DrainWorker stops handing chunks to a worker. Chunks it holds may still be
+// completed, after which the worker is told to exit when it next asks for work.
+func (c *Coordinator) DrainWorker(workerID string) error {
+	c.Mutex.Lock()
+	defer c.Mutex.Unlock()
+
+	worker, exists := c.Workers[workerID]
+	if !exists {
+		return fmt.Errorf("%w (%s)", ErrUnknownWorker, workerID)
+	}
+
+	worker.Draining = true
+	slog.Info("Draining worker", "worker_id", workerID, "active_chunks", len(worker.ActiveChunks))
+
+	return nil
+}
+
+// EvictWorker removes a worker from the pool and queues its chunks again straight
+// away. Results the worker sends afterwards are rejected.
+func (c *Coordinator) EvictWorker(workerID string) error {
+	c.Mutex.Lock()
+	defer c.Mutex.Unlock()
+
+	if _, exists := c.Workers[workerID]; !exists {
+		return fmt.Errorf("%w (%s)", ErrUnknownWorker, workerID)
+	}
+
+	reassigned := 0
+	for _, assignment := range c.Assignments {
+		if assignment.State != AssignmentActive || assignment.WorkerID != workerID {
+			continue
+		}
+
+		c.expireAssignment(assignment)
+		job := c.Jobs[c.Chunks[assignment.ChunkID].JobID]
+		if c.chunkVerified(assignment.ChunkID) || job.Terminal() {
+			c.releaseAssignment(assignment)
+			continue
+		}
+
+		c.requeueChunk(assignment.ChunkID)
+		reassigned++
+	}
+
+	delete(c.Workers, workerID)
+	slog.Info("Evicted worker", "worker_id", workerID, "reassigned_chunks", reassigned)
+	c.Events.Publish(Event{Type: EventWorkerLost, WorkerID: workerID, Reason: "evicted"})
+
+	return nil
+}
+
+func summarizeWorker(worker *WorkerInfo) WorkerSummary {
+	state := WorkerActive
+	if worker.Lost {
+		state = WorkerLost
+	}
+	if worker.Draining {
+		state = WorkerDraining
+		if len(worker.ActiveChunks) == 0 {
+			state = WorkerDrained
+		}
+	}
+
+	throughput := make(map[AlgorithmType]float64, len(worker.Throughput))
+	for algorithm, rate := range worker.Throughput {
+		throughput[algorithm] = rate
+	}
+
+	return WorkerSummary{
+		ID:              worker.ID,
+		Version:         worker.Version,
+		State:           state,
+		RegisteredAt:    worker.RegisteredAt,
+		LastHeartbeat:   worker.LastHeartbeat,
+		ActiveChunks:    append([]string{}, worker.ActiveChunks...),
+		CompletedChunks: worker.CompletedJobs,
+		Agreements:      worker.Agreements,
+		Disagreements:   worker.Disagreements,
+		Unreliable:      worker.Unreliable,
+		Throughput:      throughput,
+	}
+}